@@ -7,9 +7,9 @@ import (
 	"context"
 	"fmt"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"strconv"
-	"path/filepath"
 	"strings"
 
 	"vsync/logger"
@@ -26,7 +26,7 @@ const UNKNOWN_ERROR = 30
 
 // DartagnanChecker wraps the Dartagnan model checker by Hernan Ponce de Leon et al.
 type DartagnanChecker struct {
-	mm MemoryModel
+	mm      MemoryModel
 	version Version
 }
 
@@ -54,9 +54,9 @@ func NewDartagnan(mm MemoryModel) *DartagnanChecker {
 
 func (c *DartagnanChecker) setVersion() {
 	dartagnanHome := tools.GetEnv("DARTAGNAN_HOME")
-	args := append([]string{"-jar",
+	args := []string{"-jar",
 		dartagnanHome + "/dartagnan/target/dartagnan.jar", "--version",
-	})
+	}
 	ctx := context.Background()
 	javaCmd, err := tools.FindCmd("DARTAGNAN_JAVA_CMD")
 	if err != nil {
@@ -121,12 +121,12 @@ func catFilePath(mm MemoryModel) string {
 	return tools.ToSlash(cpath)
 }
 
-func (c *DartagnanChecker) run(ctx context.Context, testFn string) (string, error) {
+func (c *DartagnanChecker) run(ctx context.Context, testFn, boundFn string) (string, error) {
 
 	opts := []string{
 		"--encoding.wmm.idl2sat=true",
-		"--bound.load=bound.csv",
-		"--bound.save=bound.csv",
+		"--bound.load=" + boundFn,
+		"--bound.save=" + boundFn,
 		fmt.Sprintf("--target=%s", models[c.mm].arch),
 		catFilePath(c.mm),
 	}
@@ -163,7 +163,17 @@ func (c *DartagnanChecker) run(ctx context.Context, testFn string) (string, erro
 }
 
 // Check performs a check run with Dartagnan
-func (c *DartagnanChecker) Check(ctx context.Context, m DumpableModule) (cr CheckResult, err error) {
+func (c *DartagnanChecker) Check(ctx context.Context, m DumpableModule) (CheckResult, error) {
+	// the bounds saved by Dartagnan are kept while increasing them
+	boundFn, err := tools.TempName("dartagnan-bound-*.csv")
+	if err != nil {
+		return CheckResult{}, err
+	}
+	defer tools.Remove(boundFn)
+	return c.check(ctx, m, boundFn)
+}
+
+func (c *DartagnanChecker) check(ctx context.Context, m DumpableModule, boundFn string) (cr CheckResult, err error) {
 	testFn, err := tools.Touch("dartagnan-*.ll")
 	if err != nil {
 		return cr, err
@@ -173,7 +183,7 @@ func (c *DartagnanChecker) Check(ctx context.Context, m DumpableModule) (cr Chec
 	if err = tools.Dump(m, testFn); err != nil {
 		return cr, err
 	}
-	sout, err := c.run(ctx, testFn)
+	sout, err := c.run(ctx, testFn, boundFn)
 	if ctx.Err() == context.Canceled {
 		return cr, nil
 	}
@@ -187,15 +197,15 @@ func (c *DartagnanChecker) Check(ctx context.Context, m DumpableModule) (cr Chec
 		exiterr := err.(*exec.ExitError)
 		if exiterr.ExitCode() == BOUNDED_RESULT {
 			logger.Debug("Increasing the unrolling bounds")
-			result, _ = c.Check(ctx, m)
+			result, _ = c.check(ctx, m, boundFn)
 		}
 		switch exiterr.ExitCode() {
-			case PROGRAM_SPEC_VIOLATION, CAT_SPEC_VIOLATION:
-				result = CheckResult{Status: CheckNotSafe, Output: sout}
-			case TERMINATION_VIOLATION:
-				result = CheckResult{Status: CheckNotLive, Output: sout}
-			case UNKNOWN_ERROR:
-				result = CheckResult{Status: CheckRejected, Output: sout}
+		case PROGRAM_SPEC_VIOLATION, CAT_SPEC_VIOLATION:
+			result = CheckResult{Status: CheckNotSafe, Output: sout}
+		case TERMINATION_VIOLATION:
+			result = CheckResult{Status: CheckNotLive, Output: sout}
+		case UNKNOWN_ERROR:
+			result = CheckResult{Status: CheckRejected, Output: sout}
 		}
 	} else {
 		result = CheckResult{Status: CheckOK, Output: sout}
@@ -204,7 +214,6 @@ func (c *DartagnanChecker) Check(ctx context.Context, m DumpableModule) (cr Chec
 		text := `Zero violating behaviors found. If your code uses __VERIFIER_assume(...), be sure you know what you are doing!`
 		result = CheckResult{Status: CheckRejected, Output: text}
 	}
-	tools.Remove("bound.csv")
 	return result, nil
 }
 
@@ -29,7 +29,6 @@ func init() {
 type GenMCChecker struct {
 	threads uint
 	mm      MemoryModel
-	results []CheckResult
 	version Version
 }
 
@@ -84,8 +83,8 @@ func (c *GenMCChecker) GetVersion() string {
 	return fmt.Sprintf("v%d.%d.%d", c.version.major, c.version.minor, c.version.patch)
 }
 
-func (c *GenMCChecker) checkOne(ctx context.Context, genmcCmd []string, opts []string, i int) error {
-	if len(c.results) <= i {
+func (c *GenMCChecker) checkOne(ctx context.Context, genmcCmd []string, opts []string, results []CheckResult, i int) error {
+	if len(results) <= i {
 		return fmt.Errorf("unexpected index: %d", i)
 	}
 
@@ -99,7 +98,7 @@ func (c *GenMCChecker) checkOne(ctx context.Context, genmcCmd []string, opts []s
 	}
 	if ctx.Err() == context.DeadlineExceeded {
 		// deadline reached, should be ok though
-		c.results[i] = CheckResult{Status: CheckTimeout}
+		results[i] = CheckResult{Status: CheckTimeout}
 		return nil
 	}
 	fOutput := c.filterOutput(out)
@@ -121,9 +120,9 @@ func (c *GenMCChecker) checkOne(ctx context.Context, genmcCmd []string, opts []s
 			return fmt.Errorf("%s", out)
 		}
 		if !c.doesTerminate(out) {
-			c.results[i] = CheckResult{Status: CheckNotLive, Output: fOutput}
+			results[i] = CheckResult{Status: CheckNotLive, Output: fOutput}
 		} else {
-			c.results[i] = CheckResult{Status: CheckNotSafe, Output: fOutput}
+			results[i] = CheckResult{Status: CheckNotSafe, Output: fOutput}
 		}
 		return nil
 	}
@@ -146,10 +145,10 @@ func (c *GenMCChecker) checkOne(ctx context.Context, genmcCmd []string, opts []s
 		text := `
 Zero executions explored.
 If your code uses __VERIFIER_assume(...), be sure you know what you are doing!`
-		c.results[i] = CheckResult{Status: CheckRejected, Output: text, NumExecutions: 0}
+		results[i] = CheckResult{Status: CheckRejected, Output: text, NumExecutions: 0}
 
 	} else {
-		c.results[i] = CheckResult{Status: CheckOK, Output: fOutput, NumExecutions: execNums}
+		results[i] = CheckResult{Status: CheckOK, Output: fOutput, NumExecutions: execNums}
 	}
 	logger.Infof("Genmc output: %s", fOutput)
 	return nil
@@ -202,22 +201,22 @@ func (c *GenMCChecker) getOpts() ([]string, error) {
 	return extendedOpts, nil
 }
 
-func (c *GenMCChecker) checkResult(err error) (CheckResult, error) {
+func (c *GenMCChecker) checkResult(results []CheckResult, err error) (CheckResult, error) {
 	if err != nil {
 		logger.Debugf("===== genmc failed =====\n%v\n========================", err)
 		return CheckResult{}, err
 	}
-	for _, r := range c.results {
+	for _, r := range results {
 		if r.Status == CheckNotLive || r.Status == CheckNotSafe || r.Status == CheckRejected {
 			return r, nil
 		}
 	}
-	for _, r := range c.results {
+	for _, r := range results {
 		if r.Status == CheckOK {
 			return r, nil
 		}
 	}
-	for _, r := range c.results {
+	for _, r := range results {
 		if r.Status == CheckTimeout {
 			return r, nil
 		}
@@ -264,15 +263,17 @@ func (c *GenMCChecker) Check(ctx context.Context, m DumpableModule) (cr CheckRes
 		optGroups = append(optGroups, opts)
 	}
 
-	c.results = make([]CheckResult, len(optGroups))
+	// the results are local to the call, so that a checker can be used by
+	// concurrent checks
+	results := make([]CheckResult, len(optGroups))
 	for i, opts := range optGroups {
 		i, opts := i, opts
 		g.Go(func() error {
 			defer cancel()
-			return c.checkOne(ctx, genmcCmd, opts, i)
+			return c.checkOne(ctx, genmcCmd, opts, results, i)
 		})
 	}
-	return c.checkResult(g.Wait())
+	return c.checkResult(results, g.Wait())
 }
 
 func (c *GenMCChecker) doesTerminate(str string) bool {
@@ -317,7 +318,7 @@ func genMCIncludePaths() []string {
 	if err != nil {
 		logger.Fatalf("could not create temporary file: %v", err)
 	}
-	defer os.Remove(fn)
+	defer tools.Remove(fn)
 
 	err = os.WriteFile(fn, []byte(tinyProgram), 0644)
 	if err != nil {
@@ -335,7 +336,7 @@ func genMCIncludePaths() []string {
 	if err != nil {
 		logger.Fatalf("could not run clang: %v", err)
 	}
-	defer os.Remove(fnll)
+	defer tools.Remove(fnll)
 
 	genmcCmd, err := tools.FindCmd("GENMC_CMD")
 	if err != nil {
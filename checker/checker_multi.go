@@ -0,0 +1,109 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package checker
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Multi checks a module with several checkers, typically one per memory
+// model. A module is correct only if it is correct with all of them. The
+// checkers that failed most often are run first, then the fastest ones; the
+// check stops at the first failure.
+type Multi struct {
+	// OnResult is called after each check of a module with one checker.
+	OnResult func(name string, r CheckResult, elapsed time.Duration)
+
+	names []string
+	tools []Tool
+
+	mu    sync.Mutex // protects the measurements during concurrent checks
+	fails []int
+	runs  []int
+	times []time.Duration
+}
+
+// NewMulti returns a checker combining the given checkers. Each checker has a
+// name, eg, its memory model, used in OnResult.
+func NewMulti(names []string, tools []Tool) *Multi {
+	return &Multi{
+		names: names,
+		tools: tools,
+		fails: make([]int, len(tools)),
+		runs:  make([]int, len(tools)),
+		times: make([]time.Duration, len(tools)),
+	}
+}
+
+// order returns the indices of the checkers in the order they are run.
+func (c *Multi) order() []int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	idx := make([]int, len(c.tools))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		a, b := idx[i], idx[j]
+		if c.fails[a] != c.fails[b] {
+			return c.fails[a] > c.fails[b]
+		}
+		return c.mean(a) < c.mean(b)
+	})
+	return idx
+}
+
+// mean returns the mean time of the checks with the i-th checker.
+func (c *Multi) mean(i int) time.Duration {
+	if c.runs[i] == 0 {
+		return 0
+	}
+	return c.times[i] / time.Duration(c.runs[i])
+}
+
+func (c *Multi) record(i int, r CheckResult, elapsed time.Duration) {
+	c.mu.Lock()
+	if r.Status != CheckOK {
+		c.fails[i]++
+	}
+	c.runs[i]++
+	c.times[i] += elapsed
+	c.mu.Unlock()
+
+	if c.OnResult != nil {
+		c.OnResult(c.names[i], r, elapsed)
+	}
+}
+
+// Check runs the checkers one after the other until one of them does not
+// return CheckOK. It returns the result of that checker or of the last one.
+func (c *Multi) Check(ctx context.Context, m DumpableModule) (CheckResult, error) {
+	var r CheckResult
+	for _, i := range c.order() {
+		ts := time.Now()
+		var err error
+		r, err = c.tools[i].Check(ctx, m)
+		if ctx.Err() == context.Canceled {
+			return r, err
+		}
+		c.record(i, r, time.Since(ts))
+		if err != nil || r.Status != CheckOK {
+			return r, err
+		}
+	}
+	return r, nil
+}
+
+// GetVersion returns the versions of the checkers.
+func (c *Multi) GetVersion() string {
+	var versions []string
+	for i, t := range c.tools {
+		versions = append(versions, c.names[i]+": "+t.GetVersion())
+	}
+	return strings.Join(versions, ", ")
+}
@@ -0,0 +1,45 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package checker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMulti(t *testing.T) {
+	var (
+		tso  = &Mock{Result: CheckResult{Status: CheckOK}}
+		arm8 = &Mock{Result: CheckResult{Status: CheckOK}}
+		c    = NewMulti([]string{"tso", "arm8"}, []Tool{tso, arm8})
+		runs []string
+	)
+	c.OnResult = func(name string, _ CheckResult, _ time.Duration) {
+		runs = append(runs, name)
+	}
+
+	// correct with all memory models
+	r, err := c.Check(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, CheckOK, r.Status)
+	assert.ElementsMatch(t, []string{"tso", "arm8"}, runs)
+
+	// the check stops at the first failure
+	arm8.Result.Status = CheckNotSafe
+	runs = nil
+	r, _ = c.Check(context.Background(), nil)
+	assert.Equal(t, CheckNotSafe, r.Status)
+	assert.Equal(t, "arm8", runs[len(runs)-1])
+
+	// the failing model is checked first from now on
+	runs = nil
+	r, _ = c.Check(context.Background(), nil)
+	assert.Equal(t, CheckNotSafe, r.Status)
+	assert.Equal(t, []string{"arm8"}, runs)
+
+	assert.Equal(t, "tso: v0.0.0, arm8: v0.0.0", c.GetVersion())
+}
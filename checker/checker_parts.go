@@ -0,0 +1,68 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package checker
+
+import (
+	"context"
+	"time"
+)
+
+// Part is a module checked on its own as part of a composite module.
+type Part struct {
+	Name   string
+	Module DumpableModule
+}
+
+// CompositeModule is a module made of parts that are checked separately, eg,
+// the harnesses of a library.
+type CompositeModule interface {
+	DumpableModule
+	Parts() []Part
+}
+
+// Parts checks each part of composite modules with a checker. A composite
+// module is correct only if all its parts are correct; the check stops at the
+// first part that is not. Other modules are checked as they are.
+type Parts struct {
+	// OnResult is called after the check of each part.
+	OnResult func(name string, r CheckResult, elapsed time.Duration)
+
+	tool Tool
+}
+
+// NewParts returns a checker of the parts of composite modules.
+func NewParts(t Tool) *Parts {
+	return &Parts{tool: t}
+}
+
+// Check checks the parts of the module one after the other until one of them
+// does not return CheckOK. It returns the result of that part or of the last
+// one.
+func (c *Parts) Check(ctx context.Context, m DumpableModule) (CheckResult, error) {
+	cm, ok := m.(CompositeModule)
+	if !ok {
+		return c.tool.Check(ctx, m)
+	}
+	var r CheckResult
+	for _, p := range cm.Parts() {
+		ts := time.Now()
+		var err error
+		r, err = c.tool.Check(ctx, p.Module)
+		if ctx.Err() == context.Canceled {
+			return r, err
+		}
+		if c.OnResult != nil {
+			c.OnResult(p.Name, r, time.Since(ts))
+		}
+		if err != nil || r.Status != CheckOK {
+			return r, err
+		}
+	}
+	return r, nil
+}
+
+// GetVersion returns the version of the checker.
+func (c *Parts) GetVersion() string {
+	return c.tool.GetVersion()
+}
@@ -0,0 +1,59 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package checker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type stringModule string
+
+func (m stringModule) String() string { return string(m) }
+
+type compositeModule []Part
+
+func (m compositeModule) String() string { return "composite" }
+func (m compositeModule) Parts() []Part  { return m }
+
+// verdictChecker returns the status of each module by its text.
+type verdictChecker map[string]CheckStatus
+
+func (c verdictChecker) Check(_ context.Context, m DumpableModule) (CheckResult, error) {
+	return CheckResult{Status: c[m.String()]}, nil
+}
+
+func (c verdictChecker) GetVersion() string { return "v0.0.0" }
+
+func TestParts(t *testing.T) {
+	var (
+		c = NewParts(verdictChecker{
+			"a": CheckOK, "b": CheckNotSafe, "c": CheckOK, "single": CheckNotLive,
+		})
+		checked []string
+	)
+	c.OnResult = func(name string, _ CheckResult, _ time.Duration) {
+		checked = append(checked, name)
+	}
+
+	r, err := c.Check(context.Background(), compositeModule{{"A", stringModule("a")}, {"C", stringModule("c")}})
+	assert.Nil(t, err)
+	assert.Equal(t, CheckOK, r.Status)
+	assert.Equal(t, []string{"A", "C"}, checked)
+
+	// the check stops at the failing part
+	checked = nil
+	r, _ = c.Check(context.Background(), compositeModule{
+		{"A", stringModule("a")}, {"B", stringModule("b")}, {"C", stringModule("c")},
+	})
+	assert.Equal(t, CheckNotSafe, r.Status)
+	assert.Equal(t, []string{"A", "B"}, checked)
+
+	// other modules are checked as they are
+	r, _ = c.Check(context.Background(), stringModule("single"))
+	assert.Equal(t, CheckNotLive, r.Status)
+}
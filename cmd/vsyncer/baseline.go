@@ -0,0 +1,235 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"vsync/logger"
+	"vsync/module"
+	"vsync/tools"
+)
+
+const baselineDoc = `
+Pins the memory orderings of the operations of harnesses to detect regressions.
+
+   record  analyzes the harness and stores its operations in the baseline
+   verify  analyzes the harnesses and compares them with the baseline
+
+A baseline file holds, per harness, the operations with their source
+location, function, kind and memory ordering. The input files of a harness are
+given as arguments as for 'vsyncer check'. Without arguments, verify checks all
+harnesses of the baseline. Added, removed and changed operations are reported
+and the exit code is non-zero if any harness drifted from the baseline.
+
+Files below the directory of the baseline file, ie, input files and source
+locations, are stored relative to it, so that a baseline recorded in one
+checkout of a repository can be verified in another one.
+`
+
+const baselineVersion = 1
+
+var baselineFlags struct {
+	file string
+}
+
+// baselineFile is the format of the baseline file.
+type baselineFile struct {
+	Version   int               `json:"version"`
+	Harnesses []baselineHarness `json:"harnesses"`
+}
+
+type baselineHarness struct {
+	Name       string                 `json:"name"`
+	Args       []string               `json:"args"`
+	Operations []module.BaselineEntry `json:"operations"`
+}
+
+func init() {
+	var baselineCmd = cobra.Command{
+		Use:   "baseline",
+		Short: "Records and verifies the expected orderings of harnesses",
+		Long:  baselineDoc,
+	}
+	baselineCmd.PersistentFlags().StringVarP(&baselineFlags.file, "baseline", "b", "vsyncer-baseline.json",
+		"baseline file")
+	addMutateFlags(baselineCmd.PersistentFlags())
+
+	baselineCmd.AddCommand(&cobra.Command{
+		Use:   "record [flags] <input.ll|input.c>...",
+		Short: "Stores the operations of a harness in the baseline",
+		Args:  IsArgsn,
+		RunE: withTarget(func(cmd *cobra.Command, args []string) error {
+			return recordBaseline(baselineFlags.file, args)
+		}),
+	})
+	baselineCmd.AddCommand(&cobra.Command{
+		Use:   "verify [flags] [input.ll|input.c]...",
+		Short: "Compares harnesses with the baseline",
+		RunE: withTarget(func(cmd *cobra.Command, args []string) error {
+			return verifyBaseline(baselineFlags.file, args)
+		}),
+	})
+	rootCmd.AddCommand(&baselineCmd)
+}
+
+// analyzeHarness compiles the harness and returns its operations for a
+// baseline in the directory dir.
+func analyzeHarness(dir string, args []string) ([]module.BaselineEntry, error) {
+	fn := newOutputGenerator(args)("")
+	fn, remove, err := compileConditional(fn, args)
+	if err != nil {
+		return nil, err
+	} else if remove {
+		defer tools.Remove(fn)
+	}
+	m, err := mutate(fn, liftSelection, orderSelection)
+	if err != nil {
+		return nil, err
+	}
+	defer m.Cleanup()
+	return m.Baseline(dir), nil
+}
+
+// baselineArgs returns the arguments of a harness with the input files as
+// stored in a baseline in the directory dir, see module.BaselinePath.
+func baselineArgs(dir string, args []string) []string {
+	var r []string
+	for _, a := range args {
+		if reIsLLOrC.MatchString(a) {
+			a = module.BaselinePath(dir, a)
+		}
+		r = append(r, a)
+	}
+	return r
+}
+
+// harnessArgs returns the arguments of a harness of a baseline in the
+// directory dir with the input files usable from the working directory.
+func harnessArgs(dir string, args []string) []string {
+	var r []string
+	for _, a := range args {
+		if reIsLLOrC.MatchString(a) {
+			a = tools.ResolvePath(dir, a)
+		}
+		r = append(r, a)
+	}
+	return r
+}
+
+func loadBaseline(fn string) (baselineFile, error) {
+	b := baselineFile{Version: baselineVersion}
+	data, err := os.ReadFile(tools.FromSlash(fn))
+	if err != nil {
+		return b, err
+	}
+	if err := json.Unmarshal(data, &b); err != nil {
+		return b, fmt.Errorf("%s: %v", fn, err)
+	}
+	if b.Version != baselineVersion {
+		return b, fmt.Errorf("%s: unsupported baseline version %d", fn, b.Version)
+	}
+	return b, nil
+}
+
+func (b baselineFile) save(fn string) error {
+	sort.Slice(b.Harnesses, func(i, j int) bool {
+		return b.Harnesses[i].Name < b.Harnesses[j].Name
+	})
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(tools.FromSlash(fn), append(data, '\n'), fileMode)
+}
+
+// find returns the harness with the given name or nil.
+func (b *baselineFile) find(name string) *baselineHarness {
+	for i := range b.Harnesses {
+		if b.Harnesses[i].Name == name {
+			return &b.Harnesses[i]
+		}
+	}
+	return nil
+}
+
+// recordBaseline adds the harness to the baseline or replaces it.
+func recordBaseline(fn string, args []string) error {
+	b, err := loadBaseline(fn)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return verror(internalError, err)
+	}
+	dir := filepath.Dir(tools.FromSlash(fn))
+	ops, err := analyzeHarness(dir, args)
+	if err != nil {
+		return err
+	}
+
+	args = baselineArgs(dir, args)
+	name := strings.Join(args, " ")
+	if h := b.find(name); h != nil {
+		h.Args = args
+		h.Operations = ops
+	} else {
+		b.Harnesses = append(b.Harnesses, baselineHarness{Name: name, Args: args, Operations: ops})
+	}
+	if err := b.save(fn); err != nil {
+		return verror(internalError, err)
+	}
+	logger.Printf("Recorded %d operations of %s in %s\n", len(ops), name, fn)
+	return nil
+}
+
+// verifyBaseline compares the harness given as arguments, or all harnesses of
+// the baseline, with the baseline.
+func verifyBaseline(fn string, args []string) error {
+	b, err := loadBaseline(fn)
+	if err != nil {
+		return verror(internalError, err)
+	}
+
+	var (
+		dir       = filepath.Dir(tools.FromSlash(fn))
+		harnesses = b.Harnesses
+	)
+	if len(args) > 0 {
+		name := strings.Join(baselineArgs(dir, args), " ")
+		h := b.find(name)
+		if h == nil {
+			return fmt.Errorf("harness %q not in baseline %s", name, fn)
+		}
+		harnesses = []baselineHarness{*h}
+	}
+
+	drifted := 0
+	for _, h := range harnesses {
+		ops, err := analyzeHarness(dir, harnessArgs(dir, h.Args))
+		if err != nil {
+			return err
+		}
+		drifts := module.CompareBaseline(h.Operations, ops)
+		if len(drifts) == 0 {
+			logger.Printf("%s: OK\n", h.Name)
+			continue
+		}
+		drifted++
+		logger.Printf("%s: %d differences\n", h.Name, len(drifts))
+		for _, d := range drifts {
+			logger.Printf("  %-8s  %v\n", d.Kind(), d)
+		}
+	}
+	if drifted > 0 {
+		logger.Printf("\n%d of %d harnesses drifted from %s\n", drifted, len(harnesses), fn)
+		return &vError{typ: checkFail, err: fmt.Errorf("baseline drift")}
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBaselineArgs(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.Nil(t, err)
+	var (
+		dir  = filepath.Dir(wd)
+		args = []string{"queue.c", filepath.Join(dir, "lib", "queue.c"), "-DSIZE=4"}
+	)
+
+	// input files are stored relative to the baseline, flags as they are
+	stored := baselineArgs(dir, args)
+	assert.Equal(t, []string{filepath.Base(wd) + "/queue.c", "lib/queue.c", "-DSIZE=4"}, stored)
+
+	// and found from any working directory
+	assert.Equal(t, []string{filepath.Join(dir, filepath.Base(wd), "queue.c"), filepath.Join(dir, "lib", "queue.c"),
+		"-DSIZE=4"}, harnessArgs(dir, stored))
+}
@@ -23,13 +23,16 @@ var checkFlags = struct {
 	memoryModel string
 	csvFile     string
 	timeout     time.Duration
+
+	watch         bool
+	watchInterval time.Duration
 }{}
 
 var checkCmd = cobra.Command{
 	Use:   "check [flags] <input.ll|input.c>",
 	Short: "Checks input file given a mutation bitseq",
 	Args:  IsArgsn,
-	RunE:  checkRun,
+	RunE:  withTarget(checkRun),
 
 	DisableFlagsInUseLine: true,
 }
@@ -40,8 +43,13 @@ func init() {
 	flags := checkCmd.PersistentFlags()
 	flags.StringVar(&checkFlags.csvFile, "csv-log", "", "CSV file to append the final result to ")
 	flags.DurationVar(&checkFlags.timeout, "timeout", 0, "Check timeout, e.g., 1s for 1 second, 1m for 1 minute.\nCheck will fail if the model checker did not finish within the given time.\ntimeout 0 is equivalent to no timeout")
+	flags.BoolVar(&checkFlags.watch, "watch", false,
+		"re-check whenever the input files or the headers they include change")
+	flags.DurationVar(&checkFlags.watchInterval, "watch-interval", 500*time.Millisecond,
+		"interval to poll the watched files for changes")
 	addCheckFlags(flags)
 	addMutateFlags(flags)
+	addSarifFlag(flags)
 	rootCmd.AddCommand(&checkCmd)
 }
 
@@ -72,6 +80,12 @@ func checkResults(result checker.CheckResult, m *module.History, dur time.Durati
 }
 
 func checkRun(_ *cobra.Command, args []string) (err error) {
+	if checkFlags.watch {
+		if checkFlags.watchInterval <= 0 {
+			return fmt.Errorf("--watch-interval must be positive")
+		}
+		return watchRun(args)
+	}
 	var (
 		outputGen = newOutputGenerator(args)
 		fn        = outputGen("")
@@ -95,21 +109,22 @@ func checkRun(_ *cobra.Command, args []string) (err error) {
 		}.save(checkFlags.csvFile)
 	}()
 
-	if hasToCompile(args) {
-		if err = Compile(fn, args...); err != nil {
-			return
-		}
-		defer tools.Remove(fn)
+	input, remove, err := compileConditional(fn, args)
+	if err != nil {
+		return
+	} else if remove {
+		defer tools.Remove(input)
 	}
 
 	var m *module.History
-	if m, err = mutate(fn, liftSelection, orderSelection); err != nil {
+	if m, err = mutate(input, liftSelection, orderSelection); err != nil {
 		return
 	}
 	if m == nil {
 		return errors.New("unexpected nil history pointer")
 	}
 	defer m.Cleanup()
+	warnUnreachable(m)
 
 	var chkr checker.Tool
 	if chkr, err = newChecker(checkerID, mm); err != nil {
@@ -131,10 +146,15 @@ func checkRun(_ *cobra.Command, args []string) (err error) {
 	}
 
 	err = checkResults(result, m, time.Since(ts))
-	if fn := rootFlags.outputFn; fn != "" {
-		if lerr := tools.Dump(m, fn); lerr != nil {
-			logger.Debug(lerr)
-		}
+	if lerr := saveOutput(m); lerr != nil {
+		logger.Debug(lerr)
+	}
+	var report sarifReport
+	if result.Status != checker.CheckOK {
+		report.addFailure(fn, result)
+	}
+	if lerr := report.save(sarifFile); lerr != nil {
+		logger.Warnf("could not write SARIF file: %v", lerr)
 	}
 	return
 }
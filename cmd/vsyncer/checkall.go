@@ -0,0 +1,372 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+
+	"vsync/checker"
+	"vsync/logger"
+	"vsync/tools"
+)
+
+const checkAllDoc = `
+Compiles and checks many harnesses concurrently and prints a table with the
+status of each harness. Each argument is a harness. With --manifest, each line
+of the given file is a harness, ie, its input files and compilation flags
+separated by spaces; empty lines and lines starting with # are ignored:
+
+    # harness files and flags
+    test/ttaslock.c
+    test/queue.c lib/queue.c -DQUEUE_SIZE=4
+
+With --target and no harness argument, the files of the target are checked as
+one harness.
+
+With --csv-log, a row per harness is appended to the given CSV file as with
+'vsyncer check'; --json writes the results as a JSON array. The exit code is
+non-zero if any harness fails.
+`
+
+var checkAllFlags struct {
+	jobs     uint
+	manifest string
+	json     string
+}
+
+// harness is a program checked by check-all.
+type harness struct {
+	name string
+	args []string
+}
+
+// harnessResult is the outcome of checking a harness.
+type harnessResult struct {
+	harness
+	status        checker.CheckStatus
+	numExecutions int
+	version       string
+	output        string
+	duration      time.Duration
+	err           error
+}
+
+func init() {
+	var checkAllCmd = cobra.Command{
+		Use:   "check-all [flags] [harness.c|harness.ll]...",
+		Short: "Checks many harnesses concurrently",
+		Long:  checkAllDoc,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if checkAllFlags.manifest != "" {
+				return nil
+			}
+			return IsArgsn(cmd, args)
+		},
+		RunE: checkAllRun,
+
+		DisableFlagsInUseLine: true,
+	}
+	flags := checkAllCmd.PersistentFlags()
+	flags.UintVarP(&checkAllFlags.jobs, "jobs", "j", 0, "number of harnesses checked concurrently (default: half of the CPUs)")
+	flags.StringVar(&checkAllFlags.manifest, "manifest", "", "file with a harness per line")
+	flags.StringVar(&checkAllFlags.json, "json", "", "JSON file to write the results to")
+	flags.StringVar(&checkFlags.csvFile, "csv-log", "", "CSV file to append a row per harness to")
+	flags.DurationVar(&checkFlags.timeout, "timeout", 0, "timeout of each check, 0 for no timeout")
+	addCheckFlags(flags)
+	addMutateFlags(flags)
+	rootCmd.AddCommand(&checkAllCmd)
+}
+
+func checkAllRun(_ *cobra.Command, args []string) error {
+	harnesses, err := loadHarnesses(args, checkAllFlags.manifest)
+	if err != nil {
+		return err
+	}
+	th, err := targetHarness(args)
+	if err != nil {
+		return err
+	}
+	harnesses = append(th, harnesses...)
+	if len(harnesses) == 0 {
+		return fmt.Errorf("no harness given")
+	}
+
+	var (
+		results = make([]harnessResult, len(harnesses))
+		g       errgroup.Group
+		mu      sync.Mutex
+		done    int
+	)
+	g.SetLimit(int(defaultInstances(checkAllFlags.jobs)))
+	for i, h := range harnesses {
+		i, h := i, h
+		g.Go(func() error {
+			results[i] = checkHarness(context.Background(), h)
+
+			mu.Lock()
+			done++
+			logger.Printf("[%d/%d] %-40s %v (%v)\n", done, len(harnesses), h.name,
+				results[i].statusString(), results[i].duration.Round(time.Millisecond))
+			mu.Unlock()
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	for _, r := range results {
+		r.csvReport().save(checkFlags.csvFile)
+	}
+	if err := saveHarnessJSON(checkAllFlags.json, results); err != nil {
+		return verror(internalError, err)
+	}
+	return printHarnessTable(results)
+}
+
+// targetHarness returns the files of the target as a single harness if a
+// target is given and no harness is given as argument.
+func targetHarness(args []string) ([]harness, error) {
+	if len(args) > 0 || proj == nil || proj.target == "" {
+		return nil, nil
+	}
+	files, err := inputArgs(nil)
+	if err != nil {
+		return nil, err
+	}
+	return []harness{{name: proj.target, args: files}}, nil
+}
+
+// loadHarnesses returns a harness per argument and per line of the manifest.
+func loadHarnesses(args []string, manifest string) ([]harness, error) {
+	var harnesses []harness
+	for _, a := range args {
+		harnesses = append(harnesses, harness{name: a, args: []string{a}})
+	}
+	if manifest == "" {
+		return harnesses, nil
+	}
+	fp, err := os.Open(tools.FromSlash(manifest))
+	if err != nil {
+		return nil, err
+	}
+	defer fp.Close()
+
+	// the files of the manifest are relative to its directory
+	dir := filepath.Dir(manifest)
+	scanner := bufio.NewScanner(fp)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		for i, f := range fields {
+			if !strings.HasPrefix(f, "-") && !filepath.IsAbs(f) {
+				fields[i] = filepath.Join(dir, f)
+			}
+		}
+		harnesses = append(harnesses, harness{name: line, args: fields})
+	}
+	return harnesses, scanner.Err()
+}
+
+// compileHarness compiles the harness into a temporary file if necessary. It
+// returns the module of the harness and a function removing the temporary file.
+func compileHarness(h harness) (string, func(), error) {
+	if len(h.args) == 1 && !hasToCompile(h.args) {
+		return h.args[0], func() {}, nil
+	}
+	fn, err := tools.TempName(filepath.Base(base(findFile(h.args))) + "-*.ll")
+	if err != nil {
+		return "", nil, verror(internalError, err)
+	}
+	if err := Compile(fn, h.args...); err != nil {
+		tools.Remove(fn)
+		return "", nil, err
+	}
+	return fn, func() { tools.Remove(fn) }, nil
+}
+
+// checkHarness compiles and checks a harness with the bitseqs and the checker
+// given as flags. Its output is not printed.
+func checkHarness(ctx context.Context, h harness) (r harnessResult) {
+	var (
+		ts = time.Now()
+		mm = checker.ParseMemoryModel(checkFlags.memoryModel)
+	)
+	r.harness = h
+	defer func() {
+		r.duration = time.Since(ts)
+	}()
+
+	input, remove, err := compileHarness(h)
+	if err != nil {
+		r.err = err
+		return
+	}
+	defer remove()
+
+	m, err := mutate(input, liftSelection, orderSelection)
+	if err != nil {
+		r.err = err
+		return
+	}
+	defer m.Cleanup()
+
+	chkr, err := newChecker(getCheckerID(), mm)
+	if err != nil {
+		r.err = err
+		return
+	}
+	if checkFlags.timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, checkFlags.timeout)
+		defer cancel()
+	}
+
+	result, err := chkr.Check(ctx, m)
+	r.version = chkr.GetVersion()
+	if err != nil {
+		r.err = verror(checkerError, err)
+		return
+	}
+	r.status = result.Status
+	r.numExecutions = result.NumExecutions
+	r.output = result.Output
+	if result.Status != checker.CheckOK {
+		r.err = vfail(result.Status, nil)
+	}
+	return
+}
+
+func (r harnessResult) passed() bool {
+	return r.err == nil && r.status == checker.CheckOK
+}
+
+// failed returns true if the checker found a violation.
+func (r harnessResult) failed() bool {
+	e, ok := r.err.(*vError)
+	return ok && e.typ == checkFail
+}
+
+// statusString returns the status of the check or "error" if the harness
+// could not be checked.
+func (r harnessResult) statusString() string {
+	if r.err != nil && !r.failed() {
+		return "error"
+	}
+	return fmt.Sprint(r.status)
+}
+
+func (r harnessResult) csvReport() csvReport {
+	return csvReport{
+		name:          r.name,
+		checker:       getCheckerID(),
+		version:       r.version,
+		memoryModel:   checker.ParseMemoryModel(checkFlags.memoryModel),
+		duration:      r.duration,
+		status:        r.status,
+		numExecutions: r.numExecutions,
+		err:           r.err,
+	}
+}
+
+type harnessJSON struct {
+	Name          string   `json:"name"`
+	Files         []string `json:"files"`
+	Checker       string   `json:"checker"`
+	Version       string   `json:"version"`
+	MemoryModel   string   `json:"memory_model"`
+	Status        string   `json:"status"`
+	Passed        bool     `json:"passed"`
+	Duration      float64  `json:"duration"` // in seconds
+	NumExecutions int      `json:"num_executions"`
+	Error         string   `json:"error,omitempty"`
+}
+
+// saveHarnessJSON writes the results to the file if given.
+func saveHarnessJSON(fn string, results []harnessResult) error {
+	if fn == "" {
+		return nil
+	}
+	rows := []harnessJSON{}
+	for _, r := range results {
+		row := harnessJSON{
+			Name:          r.name,
+			Files:         r.args,
+			Checker:       fmt.Sprint(getCheckerID()),
+			Version:       r.version,
+			MemoryModel:   fmt.Sprint(checker.ParseMemoryModel(checkFlags.memoryModel)),
+			Status:        r.statusString(),
+			Passed:        r.passed(),
+			Duration:      r.duration.Seconds(),
+			NumExecutions: r.numExecutions,
+		}
+		if r.err != nil && !r.failed() {
+			row.Error = r.err.Error()
+		}
+		rows = append(rows, row)
+	}
+	data, err := json.MarshalIndent(rows, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(tools.FromSlash(fn), append(data, '\n'), fileMode)
+}
+
+// printHarnessTable prints the status of each harness and returns an error if
+// any harness failed.
+func printHarnessTable(results []harnessResult) error {
+	width := len("Harness")
+	for _, r := range results {
+		if len(r.name) > width {
+			width = len(r.name)
+		}
+	}
+
+	logger.Println()
+	logger.Println("== RESULTS ===================================")
+	logger.Println()
+	logger.Printf("  %-*s  %-6s  %-16s  %12s  %10s\n", width, "Harness", "Result", "Status", "Time", "Executions")
+	passed := 0
+	for _, r := range results {
+		result := "FAIL"
+		if r.passed() {
+			result = "PASS"
+			passed++
+		}
+		logger.Printf("  %-*s  %-6s  %-16s  %12v  %10d\n", width, r.name, result,
+			r.statusString(), r.duration.Round(time.Millisecond), r.numExecutions)
+	}
+	logger.Println()
+	logger.Printf("Passed %d of %d harnesses\n", passed, len(results))
+	logger.Println()
+
+	for _, r := range results {
+		if r.err != nil && !r.failed() {
+			logger.Printf("%s: %v\n", r.name, getErrorMessage(r.err))
+		}
+	}
+
+	// violations take precedence over errors for the exit code
+	for _, r := range results {
+		if r.failed() {
+			return vfail(r.status, fmt.Errorf("%d harnesses failed", len(results)-passed))
+		}
+	}
+	if passed < len(results) {
+		return verror(internalError, fmt.Errorf("%d harnesses could not be checked", len(results)-passed))
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"vsync/checker"
+	"vsync/tools"
+)
+
+func TestLoadHarnesses(t *testing.T) {
+	dir := t.TempDir()
+	fn := filepath.Join(dir, "manifest.txt")
+	manifest := "# harnesses\n\nlock.c\n  queue.c lib/queue.c -DSIZE=4\n"
+	assert.Nil(t, os.WriteFile(fn, []byte(manifest), fileMode))
+
+	hs, err := loadHarnesses([]string{"a.ll"}, fn)
+	assert.Nil(t, err)
+	assert.Equal(t, []harness{
+		{name: "a.ll", args: []string{"a.ll"}},
+		{name: "lock.c", args: []string{filepath.Join(dir, "lock.c")}},
+		{name: "queue.c lib/queue.c -DSIZE=4", args: []string{
+			filepath.Join(dir, "queue.c"), filepath.Join(dir, "lib/queue.c"), "-DSIZE=4"}},
+	}, hs)
+
+	_, err = loadHarnesses(nil, filepath.Join(dir, "missing.txt"))
+	assert.NotNil(t, err)
+}
+
+func TestTargetHarness(t *testing.T) {
+	dir := t.TempDir()
+	fn := filepath.Join(dir, "vsyncer.yaml")
+	assert.Nil(t, os.WriteFile(fn, []byte(testConfig), fileMode))
+
+	defer func(saved struct{ config, target string }) {
+		configFlags = saved
+		proj = nil
+		tools.ResetFileEnv()
+	}(configFlags)
+	configFlags.config = fn
+	configFlags.target = "lock"
+
+	p, err := loadProject()
+	assert.Nil(t, err)
+	proj = p
+
+	// the files of the target are one harness
+	hs, err := targetHarness(nil)
+	assert.Nil(t, err)
+	assert.Len(t, hs, 1)
+	assert.Equal(t, "lock", hs[0].name)
+	assert.Len(t, hs[0].args, 2)
+
+	// unless harnesses are given
+	hs, err = targetHarness([]string{"a.ll"})
+	assert.Nil(t, err)
+	assert.Empty(t, hs)
+}
+
+func TestHarnessTable(t *testing.T) {
+	var (
+		ok      = harnessResult{harness: harness{name: "ok.c"}, status: checker.CheckOK}
+		notSafe = harnessResult{harness: harness{name: "bug.c"}, status: checker.CheckNotSafe,
+			err: vfail(checker.CheckNotSafe, nil)}
+		broken = harnessResult{harness: harness{name: "broken.c"},
+			err: verror(compilerError, errors.New("could not compile"))}
+	)
+	assert.Nil(t, printHarnessTable([]harnessResult{ok, ok}))
+
+	// violations take precedence over errors
+	err := printHarnessTable([]harnessResult{ok, broken, notSafe})
+	assert.Equal(t, int(checkFail), getErrorCode(err))
+	err = printHarnessTable([]harnessResult{ok, broken})
+	assert.Equal(t, int(internalError), getErrorCode(err))
+
+	assert.Equal(t, "error", broken.statusString())
+	assert.True(t, notSafe.failed())
+	assert.False(t, broken.failed())
+}
@@ -5,11 +5,13 @@ package main
 
 import (
 	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"vsync/checker"
-	"vsync/logger"
 	"vsync/tools"
 )
 
@@ -21,6 +23,19 @@ checker. For all checkers, the following options are passed to clang
 
 Use CFLAGS to pass further compilation flags and set CLANG to select the path
 to the clang compiler.
+
+When several input files are given, each C/C++ file is compiled separately and
+the resulting modules are linked together with any given .ll files using
+llvm-link. Flags for a single input file can be given with --file-cflags, eg,
+
+   --file-cflags "harness.c=-DNTHREADS=3 -DWITH_TRYLOCK"
+
+Set LLVM_LINK_CMD to select the path to llvm-link.
+
+LLVM bitcode (.bc) files are accepted as input and disassembled with llvm-dis.
+If the output file given with -o has the extension .bc, the resulting module is
+assembled into bitcode with llvm-as. Set LLVM_DIS_CMD and LLVM_AS_CMD to select
+the path to these tools.
 `
 
 func init() {
@@ -33,10 +48,13 @@ func init() {
 		DisableFlagsInUseLine: true,
 		TraverseChildren:      true,
 
-		RunE: func(cmd *cobra.Command, args []string) error {
+		RunE: withTarget(func(cmd *cobra.Command, args []string) error {
+			if fn := rootFlags.outputFn; reIsBC.MatchString(fn) {
+				return compileBitcode(fn, args)
+			}
 			output := newOutputGenerator(append([]string{rootFlags.outputFn}, args...))
 			return Compile(output(""), args...)
-		},
+		}),
 	}
 
 	rootCmd.AddCommand(&compileCmd)
@@ -49,7 +67,7 @@ func Compile(output string, args ...string) error {
 	case len(args) == 0:
 		return errors.New("no file argument given")
 
-	// When only one argument given, that can be a .c or an .ll file
+	// When only one argument given, that can be a .c, .ll or .bc file
 	case len(args) == 1 && reIsLL.MatchString(args[0]):
 		if err := tools.FileExists(args[0]); err != nil {
 			// If the argument is already an .ll file, simply return.
@@ -57,9 +75,24 @@ func Compile(output string, args ...string) error {
 		}
 		return tools.CopyFile(args[0], output)
 
-	// Currently we do support multiple arguments
-	case onlyLL(args...):
-		logger.Fatal("linking multiple .ll not implemented yet")
+	case len(args) == 1 && reIsBC.MatchString(args[0]):
+		if err := tools.FileExists(args[0]); err != nil {
+			return err
+		}
+		if err := tools.Disassemble(args[0], output); err != nil {
+			return verror(compilerError, err)
+		}
+		return nil
+
+	// Multiple .ll and .bc files are simply linked together
+	case onlyIR(args...):
+		if err := tools.FilesExist(args); err != nil {
+			return err
+		}
+		if err := tools.Link(args, output); err != nil {
+			return verror(compilerError, err)
+		}
+		return nil
 	default:
 	}
 
@@ -69,17 +102,87 @@ func Compile(output string, args ...string) error {
 	return nil
 }
 
+// compileBitcode compiles the arguments into a temporary .ll file and
+// assembles the result into the bitcode file ofile.
+func compileBitcode(ofile string, args []string) error {
+	tmp, err := tools.Touch(filepath.Base(base(ofile)) + "-*.ll")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tools.Remove(tmp)
+	}()
+	if err := Compile(tmp, args...); err != nil {
+		return err
+	}
+	if err := tools.Assemble(tmp, ofile); err != nil {
+		return verror(compilerError, err)
+	}
+	return nil
+}
+
+// parseFileCflags parses a list of "file=flags" entries into a map from file to flags.
+func parseFileCflags(entries []string) (map[string][]string, error) {
+	m := make(map[string][]string)
+	for _, e := range entries {
+		idx := strings.Index(e, "=")
+		if idx <= 0 {
+			return nil, fmt.Errorf("invalid file cflags '%s', expected file=flags", e)
+		}
+		fn := filepath.Clean(e[:idx])
+		m[fn] = append(m[fn], strings.Fields(e[idx+1:])...)
+	}
+	return m, nil
+}
+
 func compileSources(output string, args []string) error {
+	var sources, modules, flags []string
 	for _, f := range args {
-		if !reIsC.MatchString(f) && !reIsCPP.MatchString(f) {
-			continue
-		}
-		if err := tools.FileExists(f); err != nil {
-			return err
+		switch {
+		case reIsC.MatchString(f) || reIsCPP.MatchString(f):
+			sources = append(sources, f)
+		case reIsIR.MatchString(f):
+			modules = append(modules, f)
+		default:
+			flags = append(flags, f)
 		}
 	}
+	if err := tools.FilesExist(append(sources, modules...)); err != nil {
+		return err
+	}
+	fileCflags, err := parseFileCflags(rootFlags.fileCflags)
+	if err != nil {
+		return err
+	}
 
 	// The arguments are compilable and exist, so now we do actual compilation.
 	getOptions := checker.CompileOptions(getCheckerID())
-	return tools.Compile(args, output, getOptions())
+	options := getOptions()
+
+	// A single source file is compiled directly into the output.
+	if len(sources) == 1 && len(modules) == 0 {
+		fargs := append(append([]string{}, fileCflags[filepath.Clean(sources[0])]...), args...)
+		return tools.Compile(fargs, output, options)
+	}
+
+	// Otherwise, compile every source file separately and link the results.
+	var objs []string
+	defer func() {
+		for _, fn := range objs {
+			_ = tools.Remove(fn)
+		}
+	}()
+	for _, src := range sources {
+		obj, err := tools.Touch(filepath.Base(base(src)) + "-*.ll")
+		if err != nil {
+			return err
+		}
+		objs = append(objs, obj)
+
+		fargs := append(append([]string{}, flags...), fileCflags[filepath.Clean(src)]...)
+		if err := tools.Compile(append(fargs, src), obj, options); err != nil {
+			return fmt.Errorf("could not compile '%s': %v", src, err)
+		}
+	}
+	return tools.Link(append(objs, modules...), output)
 }
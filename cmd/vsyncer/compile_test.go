@@ -5,11 +5,14 @@ package main
 
 import (
 	"fmt"
+	"io/ioutil"
 	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
+	"vsync/core"
+	"vsync/module"
 	"vsync/tools"
 )
 
@@ -25,3 +28,93 @@ func TestCompileOK(t *testing.T) {
 		})
 	}
 }
+
+const (
+	progMain = `
+@x = dso_local global i32 0, align 4
+
+declare void @foo()
+
+define dso_local i32 @main() {
+  call void @foo()
+  fence seq_cst
+  ret i32 0
+}
+`
+	progFoo = `
+@x = external global i32, align 4
+
+define dso_local void @foo() {
+  %1 = atomicrmw add i32* @x, i32 1 seq_cst, align 4
+  ret void
+}
+`
+)
+
+func TestCompileLinkLL(t *testing.T) {
+	var fns []string
+	for _, prog := range []string{progMain, progFoo} {
+		f, err := ioutil.TempFile(".", "vsyncer_test.*.ll")
+		assert.Nil(t, err)
+		defer tools.Remove(f.Name())
+		_, err = f.WriteString(prog)
+		assert.Nil(t, err)
+		assert.Nil(t, f.Close())
+		fns = append(fns, f.Name())
+	}
+
+	output := "test_link.ll"
+	err := Compile(output, fns...)
+	assert.Nil(t, err)
+	defer tools.Remove(output)
+
+	// the fence of main and the rmw of foo are in the linked module
+	m, err := module.Load(output, module.DefaultConfig())
+	assert.Nil(t, err)
+	if m != nil {
+		assert.Equal(t, 4, m.Assignment(core.SelectionAtomic).Bs.Length())
+		m.Cleanup()
+	}
+}
+
+func TestCompileBitcode(t *testing.T) {
+	var fns []string
+	for _, prog := range []string{progMain, progFoo} {
+		f, err := ioutil.TempFile(".", "vsyncer_test.*.ll")
+		assert.Nil(t, err)
+		defer tools.Remove(f.Name())
+		_, err = f.WriteString(prog)
+		assert.Nil(t, err)
+		assert.Nil(t, f.Close())
+		fns = append(fns, f.Name())
+	}
+
+	// .ll -> .bc, compiling through a temporary file in the work directory
+	defer tools.CleanupWorkDir()
+	bc := "test_bitcode.bc"
+	assert.Nil(t, compileBitcode(bc, fns))
+	defer tools.Remove(bc)
+	assert.True(t, hasToCompile([]string{bc}))
+
+	// .bc -> .ll
+	output := "test_bitcode.ll"
+	assert.Nil(t, Compile(output, bc))
+	defer tools.Remove(output)
+
+	m, err := module.Load(output, module.DefaultConfig())
+	assert.Nil(t, err)
+	if m != nil {
+		assert.Equal(t, 4, m.Assignment(core.SelectionAtomic).Bs.Length())
+		m.Cleanup()
+	}
+}
+
+func TestParseFileCflags(t *testing.T) {
+	m, err := parseFileCflags([]string{"a.c=-DX -DY", "./b.c=-I inc", "a.c=-DZ"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"-DX", "-DY", "-DZ"}, m["a.c"])
+	assert.Equal(t, []string{"-I", "inc"}, m["b.c"])
+
+	_, err = parseFileCflags([]string{"a.c"})
+	assert.NotNil(t, err)
+}
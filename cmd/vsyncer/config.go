@@ -0,0 +1,446 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+
+	"vsync/logger"
+	"vsync/tools"
+)
+
+const configDoc = `
+Prints the effective configuration and where each value comes from.
+
+Settings are read from a project file named vsyncer.yaml (or vsyncer.yml),
+looked up from the working directory upwards, or given with --config. The
+file sets flags by their long name and environment variables in the env
+section. Targets group input files with their own settings:
+
+    checker: genmc
+    skip-func: [pthread_, __assert_fail, llvm., _VERIFIER, log_]
+    env:
+      CFLAGS: -DVSYNC_VERIFICATION
+    targets:
+      ttas:
+        files: [test/ttaslock.c]
+        memory-model: rc11
+        env:
+          GENMC_OPTIONS: -disable-spin-assume
+
+With --target, the settings of the target apply and its files are the input
+if none is given. Values are taken in the following order:
+
+    flag > environment > target > file > default
+`
+
+// configNames are the names of the project configuration file.
+var configNames = []string{"vsyncer.yaml", "vsyncer.yml"}
+
+// flagEnv maps flags to the environment variables of their default values.
+var flagEnv = map[string]string{
+	"checker":      "VSYNCER_DEFAULT_CHECKER",
+	"entry-func":   "VSYNCER_DEFAULT_ENTRY_FUNC",
+	"skip-func":    "VSYNCER_DEFAULT_SKIP_FUNC",
+	"memory-model": "VSYNCER_DEFAULT_MEMMODEL",
+	"work-dir":     "VSYNCER_WORK_DIR",
+}
+
+// configValue is a setting given as a scalar or as a list of scalars.
+type configValue []string
+
+func (v *configValue) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		*v = configValue{node.Value}
+	case yaml.SequenceNode:
+		var vals []string
+		for _, n := range node.Content {
+			if n.Kind != yaml.ScalarNode {
+				return fmt.Errorf("line %d: expected scalar value", n.Line)
+			}
+			vals = append(vals, n.Value)
+		}
+		*v = vals
+	default:
+		return fmt.Errorf("line %d: expected scalar or list value", node.Line)
+	}
+	return nil
+}
+
+// configTarget holds the settings of a target. The settings of flags are
+// all the keys other than files and env.
+type configTarget struct {
+	Files []string               `yaml:"files"`
+	Env   map[string]string      `yaml:"env"`
+	Flags map[string]configValue `yaml:",inline"`
+}
+
+type configFile struct {
+	Env     map[string]string       `yaml:"env"`
+	Targets map[string]configTarget `yaml:"targets"`
+	Flags   map[string]configValue  `yaml:",inline"`
+}
+
+// project is the configuration in effect for the current command.
+type project struct {
+	path    string // configuration file, empty if none
+	dir     string // directory of the configuration file
+	target  string
+	file    configFile
+	sources map[string]string // source of each flag and environment variable
+	environ map[string]bool   // variables set in the environment
+}
+
+var (
+	proj        *project
+	configFlags struct {
+		config string
+		target string
+	}
+)
+
+func init() {
+	flags := rootCmd.PersistentFlags()
+	flags.StringVar(&configFlags.config, "config", "",
+		"project configuration file (default: vsyncer.yaml found from the working directory)")
+	flags.StringVar(&configFlags.target, "target", "", "target of the project configuration file to use")
+
+	var configCmd = cobra.Command{
+		Use:   "config",
+		Short: "Shows the project configuration",
+		Long:  configDoc,
+	}
+	configCmd.AddCommand(&cobra.Command{
+		Use:   "show",
+		Short: "Prints the effective configuration and the source of each value",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return showConfig()
+		},
+	})
+	rootCmd.AddCommand(&configCmd)
+}
+
+// findConfig returns the configuration file given with --config or the first
+// one found from the working directory upwards. It returns an empty string if
+// there is none.
+func findConfig() (string, error) {
+	if configFlags.config != "" {
+		return configFlags.config, nil
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		for _, name := range configNames {
+			fn := filepath.Join(dir, name)
+			if _, err := os.Stat(fn); err == nil {
+				return fn, nil
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// loadProject reads the project configuration file and sets the environment
+// variables defined in it.
+func loadProject() (*project, error) {
+	p := &project{
+		target:  configFlags.target,
+		sources: make(map[string]string),
+		environ: make(map[string]bool),
+	}
+	tools.ResetFileEnv()
+	for _, ev := range tools.GetEnvvars() {
+		p.environ[ev.Name] = tools.IsEnvironEnv(ev.Name)
+	}
+
+	fn, err := findConfig()
+	if err != nil {
+		return nil, err
+	}
+	if fn != "" {
+		data, err := os.ReadFile(tools.FromSlash(fn))
+		if err != nil {
+			return nil, err
+		}
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		if err := dec.Decode(&p.file); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%s: %v", fn, err)
+		}
+		p.path = fn
+		p.dir = filepath.Dir(fn)
+	}
+
+	tgt, err := p.getTarget()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.checkFlags(); err != nil {
+		return nil, err
+	}
+
+	// the target overrides the variables of the file
+	envs := []struct {
+		vals   map[string]string
+		source string
+	}{{p.file.Env, "file"}}
+	if tgt != nil {
+		envs = append(envs, struct {
+			vals   map[string]string
+			source string
+		}{tgt.Env, "target " + p.target})
+	}
+	for _, e := range envs {
+		for k, v := range e.vals {
+			if err := tools.SetFileEnv(k, v); err != nil {
+				return nil, fmt.Errorf("%s: unknown environment variable %q", fn, k)
+			}
+			p.sources[k] = e.source
+		}
+	}
+	return p, nil
+}
+
+func (p *project) getTarget() (*configTarget, error) {
+	if p.target == "" {
+		return nil, nil
+	}
+	if p.path == "" {
+		return nil, fmt.Errorf("target %q given but no project configuration file found", p.target)
+	}
+	tgt, ok := p.file.Targets[p.target]
+	if !ok {
+		var names []string
+		for name := range p.file.Targets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown target %q, targets in %s: %s",
+			p.target, p.path, strings.Join(names, ", "))
+	}
+	return &tgt, nil
+}
+
+// checkFlags returns an error if the file sets a flag that no command has.
+func (p *project) checkFlags() error {
+	known := make(map[string]bool)
+	for _, f := range allFlags() {
+		known[f.Name] = true
+	}
+	check := func(flags map[string]configValue) error {
+		for name := range flags {
+			if !known[name] || name == "config" || name == "target" {
+				return fmt.Errorf("%s: unknown setting %q", p.path, name)
+			}
+		}
+		return nil
+	}
+	if err := check(p.file.Flags); err != nil {
+		return err
+	}
+	for _, tgt := range p.file.Targets {
+		if err := check(tgt.Flags); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setting returns the value of a flag in the target or in the file.
+func (p *project) setting(name string) (configValue, string, bool) {
+	if tgt, ok := p.file.Targets[p.target]; ok && p.target != "" {
+		if v, ok := tgt.Flags[name]; ok {
+			return v, "target " + p.target, true
+		}
+	}
+	if v, ok := p.file.Flags[name]; ok {
+		return v, "file", true
+	}
+	return nil, "", false
+}
+
+// apply sets the flags not given in the command line from the configuration.
+func (p *project) apply(flags []*pflag.Flag) error {
+	for _, f := range flags {
+		env := flagEnv[f.Name]
+		switch {
+		case f.Changed:
+			p.sources[f.Name] = "flag"
+			continue
+		case env != "" && p.environ[env]:
+			p.sources[f.Name] = "environment " + env
+			continue
+		}
+		if v, source, ok := p.setting(f.Name); ok {
+			if err := setFlag(f, v); err != nil {
+				return fmt.Errorf("%s: invalid value for %s: %v", p.path, f.Name, err)
+			}
+			p.sources[f.Name] = source
+			continue
+		}
+		if source, ok := p.sources[env]; ok && env != "" {
+			// the default of the flag comes from a variable of the file
+			vals := []string{tools.GetEnv(env)}
+			if _, ok := f.Value.(pflag.SliceValue); ok {
+				vals = strings.Split(vals[0], ",")
+			}
+			if err := setFlag(f, vals); err != nil {
+				return fmt.Errorf("%s: invalid value for %s: %v", p.path, env, err)
+			}
+			p.sources[f.Name] = source + " " + env
+			continue
+		}
+		p.sources[f.Name] = "default"
+	}
+	return nil
+}
+
+func setFlag(f *pflag.Flag, vals []string) error {
+	if sv, ok := f.Value.(pflag.SliceValue); ok {
+		return sv.Replace(vals)
+	}
+	if len(vals) != 1 {
+		return fmt.Errorf("expected a single value")
+	}
+	return f.Value.Set(vals[0])
+}
+
+// inputArgs returns the files of the target if no input file is given.
+func inputArgs(args []string) ([]string, error) {
+	if len(args) > 0 || proj == nil || proj.target == "" {
+		return args, nil
+	}
+	tgt := proj.file.Targets[proj.target]
+	if len(tgt.Files) == 0 {
+		return nil, fmt.Errorf("no input file specified")
+	}
+	var files []string
+	for _, fn := range tgt.Files {
+		fn = tools.FromSlash(fn)
+		if !filepath.IsAbs(fn) {
+			fn = filepath.Join(proj.dir, fn)
+		}
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, fn); err == nil {
+				fn = rel
+			}
+		}
+		files = append(files, fn)
+	}
+	return files, nil
+}
+
+// withTarget returns a run function that takes the files of the target as
+// input if none is given.
+func withTarget(run func(*cobra.Command, []string) error) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		args, err := inputArgs(args)
+		if err != nil {
+			return err
+		}
+		return run(cmd, args)
+	}
+}
+
+// applyConfig loads the project configuration and applies it to the flags of
+// the command.
+func applyConfig(cmd *cobra.Command) error {
+	p, err := loadProject()
+	if err != nil {
+		return err
+	}
+	var flags []*pflag.Flag
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		flags = append(flags, f)
+	})
+	if err := p.apply(flags); err != nil {
+		return err
+	}
+	proj = p
+	return nil
+}
+
+// allFlags returns the flags of all commands, the first one of each name.
+func allFlags() []*pflag.Flag {
+	var (
+		flags []*pflag.Flag
+		seen  = make(map[string]bool)
+		add   = func(f *pflag.Flag) {
+			if !seen[f.Name] && f.Name != "help" {
+				seen[f.Name] = true
+				flags = append(flags, f)
+			}
+		}
+		visit func(c *cobra.Command)
+	)
+	visit = func(c *cobra.Command) {
+		c.PersistentFlags().VisitAll(add)
+		c.LocalNonPersistentFlags().VisitAll(add)
+		for _, sub := range c.Commands() {
+			visit(sub)
+		}
+	}
+	visit(&rootCmd)
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+	return flags
+}
+
+func showConfig() error {
+	flags := allFlags()
+	if err := proj.apply(flags); err != nil {
+		return err
+	}
+	if proj.path == "" {
+		logger.Printf("Configuration file: none\n")
+	} else {
+		logger.Printf("Configuration file: %s\n", proj.path)
+	}
+	if proj.target != "" {
+		files, err := inputArgs(nil)
+		if err != nil {
+			files = nil
+		}
+		logger.Printf("Target: %s %v\n", proj.target, files)
+	}
+
+	logger.Println()
+	logger.Println("Settings")
+	for _, f := range flags {
+		if f.Name == "config" || f.Name == "target" {
+			continue
+		}
+		logger.Printf("  %-20s = %-40s (%s)\n", f.Name, f.Value.String(), proj.sources[f.Name])
+	}
+
+	logger.Println()
+	logger.Println("Environment")
+	for _, ev := range tools.GetEnvvars() {
+		source := "default"
+		if proj.environ[ev.Name] {
+			source = "environment"
+		} else if s, ok := proj.sources[ev.Name]; ok {
+			source = s
+		}
+		logger.Printf("  %-25s = %-35q (%s)\n", ev.Name, tools.GetEnv(ev.Name), source)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+
+	"vsync/tools"
+)
+
+const testConfig = `
+checker: mock
+memory-model: imm
+env:
+  CFLAGS: -DFOO
+targets:
+  lock:
+    files: [lock.c, ../lib/queue.c]
+    memory-model: rc11
+    entry-func: [main, run]
+    env:
+      CFLAGS: -DBAR
+`
+
+func TestProjectConfig(t *testing.T) {
+	dir := t.TempDir()
+	fn := filepath.Join(dir, "vsyncer.yaml")
+	assert.Nil(t, os.WriteFile(fn, []byte(testConfig), fileMode))
+
+	defer func(saved struct{ config, target string }) {
+		configFlags = saved
+		proj = nil
+		tools.ResetFileEnv()
+	}(configFlags)
+	configFlags.config = fn
+	configFlags.target = "lock"
+
+	p, err := loadProject()
+	assert.Nil(t, err)
+	assert.Equal(t, "-DBAR", tools.GetEnv("CFLAGS"))
+	assert.Equal(t, "target lock", p.sources["CFLAGS"])
+
+	var (
+		flags   = pflag.NewFlagSet("test", pflag.ContinueOnError)
+		checker = flags.String("checker", "genmc", "")
+		mm      = flags.String("memory-model", "imm", "")
+		entry   = flags.StringSlice("entry-func", []string{"main"}, "")
+		skip    = flags.StringSlice("skip-func", []string{"llvm."}, "")
+	)
+	assert.Nil(t, flags.Parse([]string{"--checker", "genmc"}))
+	var all []*pflag.Flag
+	flags.VisitAll(func(f *pflag.Flag) { all = append(all, f) })
+	assert.Nil(t, p.apply(all))
+
+	// flags take precedence over the file, the target over the file
+	assert.Equal(t, "genmc", *checker)
+	assert.Equal(t, "flag", p.sources["checker"])
+	assert.Equal(t, "rc11", *mm)
+	assert.Equal(t, "target lock", p.sources["memory-model"])
+	assert.Equal(t, []string{"main", "run"}, *entry)
+	assert.Equal(t, []string{"llvm."}, *skip)
+	assert.Equal(t, "default", p.sources["skip-func"])
+
+	proj = p
+	args, err := inputArgs(nil)
+	assert.Nil(t, err)
+	wd, err := os.Getwd()
+	assert.Nil(t, err)
+	rel, err := filepath.Rel(wd, filepath.Join(dir, "lock.c"))
+	assert.Nil(t, err)
+	assert.Equal(t, rel, args[0])
+	args, err = inputArgs([]string{"other.c"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"other.c"}, args)
+
+	configFlags.target = "unknown"
+	_, err = loadProject()
+	assert.NotNil(t, err)
+}
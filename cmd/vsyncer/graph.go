@@ -0,0 +1,88 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"vsync/logger"
+	"vsync/module"
+	"vsync/tools"
+)
+
+const graphDoc = `
+Prints the call graph reached from the entry functions in Graphviz DOT format.
+
+Thread creations are dashed edges and functions skipped by the analysis are
+gray. Each function is labeled with the number of atomic operations per memory
+ordering after applying the given bitseqs. With -o, the graph is written to the
+given file instead of the standard output, eg:
+
+    vsyncer graph -o lock.dot lock.c && dot -Tsvg lock.dot > lock.svg
+`
+
+var graphFlags struct {
+	color bool
+}
+
+func init() {
+	var graphCmd = cobra.Command{
+		Use:   "graph [flags] <input.ll|input.c>",
+		Short: "Prints the call graph of the input file(s) in DOT format",
+		Long:  graphDoc,
+		Args:  IsArgsn,
+
+		DisableFlagsInUseLine: true,
+
+		RunE: withTarget(func(cmd *cobra.Command, args []string) error {
+			var (
+				outputGen = newOutputGenerator(args)
+				fn        = outputGen("")
+			)
+			return Graph(fn, args)
+		}),
+	}
+	flags := graphCmd.PersistentFlags()
+	flags.BoolVar(&graphFlags.color, "color", false, "color the ordering counts as in the diff output")
+	addMutateFlags(flags)
+	rootCmd.AddCommand(&graphCmd)
+}
+
+// Graph compiles input, analyzes result, and prints the call graph.
+func Graph(fn string, args []string) error {
+	fn, remove, err := compileConditional(fn, args)
+	if err != nil {
+		return err
+	} else if remove {
+		defer tools.Remove(fn)
+	}
+	logger.Debugf("Graph %s", fn)
+
+	var m *module.History
+	if m, err = mutate(fn, liftSelection, orderSelection); err != nil {
+		return err
+	}
+	defer m.Cleanup()
+
+	var w io.Writer = os.Stdout
+	if out := rootFlags.outputFn; out != "" {
+		f, err := os.Create(out)
+		if err != nil {
+			return verror(internalError, err)
+		}
+		defer func() {
+			if err := f.Close(); err != nil {
+				logger.Warnf("error closing file: %v", err)
+			}
+		}()
+		w = f
+	}
+	if err := m.WriteGraph(w, graphFlags.color); err != nil {
+		return verror(internalError, err)
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"vsync/checker"
+	"vsync/core"
+	"vsync/logger"
+	"vsync/module"
+	"vsync/optimizer"
+)
+
+// openHarnesses compiles and loads each harness as a separate module. The
+// returned function cleans up the modules.
+func openHarnesses(harnesses []harness) ([]*module.History, func(), error) {
+	var (
+		ms       []*module.History
+		cleanups []func()
+	)
+	cleanup := func() {
+		for i := len(cleanups) - 1; i >= 0; i-- {
+			cleanups[i]()
+		}
+	}
+	for _, h := range harnesses {
+		fn, remove, err := compileHarness(h)
+		if err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+		cleanups = append(cleanups, remove)
+		m, err := mutate(fn, liftSelection, orderSelection)
+		if err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+		cleanups = append(cleanups, m.Cleanup)
+		warnUnreachable(m)
+		if err := m.Record(); err != nil {
+			cleanup()
+			return nil, nil, verror(internalError, err)
+		}
+		ms = append(ms, m)
+	}
+	return ms, cleanup, nil
+}
+
+// optimizeHarnessRun optimizes the orderings of a library exercised by the
+// harnesses given as arguments. The operations of the harnesses are mapped to
+// the source locations in the library and a relaxation of a location is only
+// accepted if all harnesses remain correct.
+func optimizeHarnessRun(args []string) error {
+	if optimizeFlags.insertFences || optimizeFlags.html != "" || rootFlags.outputFn != "" {
+		return fmt.Errorf("--harness cannot be combined with --insert-fences, --html or --output")
+	}
+	hs, err := loadHarnesses(args, optimizeFlags.manifest)
+	if err != nil {
+		return err
+	}
+	if len(hs) == 0 {
+		return fmt.Errorf("no harness given")
+	}
+	ms, cleanup, err := openHarnesses(hs)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	var names []string
+	for _, h := range hs {
+		names = append(names, h.name)
+	}
+
+	var (
+		sel       = core.SelectionAtomic
+		sts       = optimizer.NewStats()
+		harnesses []optimizer.Harness
+	)
+	for _, m := range ms {
+		harnesses = append(harnesses, m)
+	}
+	c, err := optimizer.NewComposite(names, harnesses, sel)
+	if err != nil {
+		return verror(internalError, err)
+	}
+
+	chkr, err := newModelsChecker(checker.ParseID(rootFlags.checker), checkFlags.memoryModel, sts)
+	if err != nil {
+		return err
+	}
+	parts := checker.NewParts(chkr)
+	parts.OnResult = func(name string, _ checker.CheckResult, elapsed time.Duration) {
+		sts.AddTime("harness "+name, elapsed)
+	}
+
+	cfg := newDriverConfig()
+	if cfg.Costs, err = newCostModel(); err != nil {
+		return err
+	}
+	ia := c.Assignment(sel)
+	cfg.Pinned = core.NewBitseq(ia.Bs.Length())
+	for h, m := range ms {
+		cfg.Pinned = cfg.Pinned.Or(c.Lift(h, m.Pinned(sel)))
+	}
+	cfg.Kinds = c.Kinds()
+	if cfg.Strategy == optimizer.Hier {
+		// the groups of a location are the ones of its first operation
+		for k := range c.Locations() {
+			h, op := c.Origin(k)
+			cfg.Groups = append(cfg.Groups, ms[h].Groups(sel)[op])
+		}
+	}
+
+	d := optimizer.NewDriver(cfg, parts, sts)
+	s, err := optimizeModule(d, c, sel, func(bs core.Bitseq) (module.BarrierCount, error) {
+		var total module.BarrierCount
+		for h, m := range ms {
+			bc, err := m.BarrierCount(core.Assignment{Bs: c.Project(h, bs), Sel: sel})
+			if err != nil {
+				return total, err
+			}
+			total.SeqCst += bc.SeqCst
+			total.Acquire += bc.Acquire
+			total.Release += bc.Release
+			total.Relaxed += bc.Relaxed
+		}
+		return total, nil
+	})
+	if err != nil {
+		return err
+	}
+	defer logger.Println(sts)
+
+	if err := printHarnessSolution(c, names, ms, cfg, ia.Bs, s.Bitseq()); err != nil {
+		return verror(internalError, err)
+	}
+	if sarifFile != "" {
+		var report sarifReport
+		for _, m := range ms {
+			report.addChanges(m.Changes())
+		}
+		if err := report.save(sarifFile); err != nil {
+			return verror(internalError, err)
+		}
+	}
+	return nil
+}
+
+// printHarnessSolution prints the orderings of the library locations before
+// and after optimization and the changes in each harness.
+func printHarnessSolution(c *optimizer.Composite, names []string, ms []*module.History, cfg optimizer.DriverConfig,
+	initial, bs core.Bitseq) error {
+	for h, m := range ms {
+		if err := m.Forget(); err != nil {
+			return err
+		}
+		if err := m.Mutate(core.Assignment{Bs: c.Project(h, bs), Sel: core.SelectionAtomic}); err != nil {
+			return err
+		}
+	}
+
+	logger.Println()
+	logger.Println("== LOCATIONS =================================")
+	logger.Println()
+	before, after := orderings(cfg.Kinds, initial), orderings(cfg.Kinds, bs)
+	for k, loc := range c.Locations() {
+		mark := " "
+		if before[k] != after[k] {
+			mark = "*"
+		}
+		logger.Printf("%s %-40s %-8v %v --> %v\n", mark, loc, cfg.Kinds[k], before[k], after[k])
+	}
+	logger.Println()
+	if cost := costEstimate(cfg); cost != nil {
+		logger.Printf("Estimated cost (%s)\n   before: %d\n   after : %d\n", costModelName(), cost(initial), cost(bs))
+		logger.Println()
+	}
+	if bs.Equals(initial) {
+		logger.Printf("Result\n   No optimization found!\n")
+		logger.Println()
+	} else {
+		logger.Printf("Result\n   Optimization found!\n")
+		logger.Println()
+		for h, m := range ms {
+			logger.Printf("Harness %s\n", names[h])
+			if err := m.PrintDiff(); err != nil {
+				logger.Println(err)
+			}
+		}
+	}
+	logger.Println("== ITERATION STATS ===========================")
+	return nil
+}
+
+// orderings returns the ordering of each operation of the bitseq.
+func orderings(kinds []core.AtomicOp, bs core.Bitseq) []core.Ordering {
+	var r []core.Ordering
+	_ = bs.Translate(2, func(k int, val int) error {
+		r = append(r, kinds[k].GetOrdering(val))
+		return nil
+	})
+	return r
+}
@@ -0,0 +1,170 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"vsync/core"
+	"vsync/logger"
+	"vsync/module"
+)
+
+const historyDoc = `
+Inspects and replays mutation sessions recorded with 'vsyncer mutate --session'.
+
+   show    prints the input, configuration and steps of the session
+   replay  replays the session and prints summary and code diff
+   undo    drops the last step of the session
+   export  replays the session and writes the mutated module to -o
+
+Use --step N to replay or export only the first N steps.
+`
+
+var historyFlags struct {
+	step int
+}
+
+func init() {
+	var historyCmd = cobra.Command{
+		Use:   "history",
+		Short: "Inspects and replays mutation sessions",
+		Long:  historyDoc,
+	}
+
+	historyCmd.AddCommand(&cobra.Command{
+		Use:   "show <session.json>",
+		Short: "Prints the steps of a session",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := module.LoadSession(args[0])
+			if err != nil {
+				return verror(internalError, err)
+			}
+			printSession(s)
+			return nil
+		},
+	})
+
+	historyCmd.AddCommand(&cobra.Command{
+		Use:   "replay <session.json>",
+		Short: "Replays a session and prints the code diff",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			m, err := replaySession(args[0])
+			if err != nil {
+				return err
+			}
+			defer m.Cleanup()
+			if err := saveOutput(m); err != nil {
+				return verror(internalError, err)
+			}
+			m.PrintSummary()
+			return m.PrintDiff()
+		},
+	})
+
+	historyCmd.AddCommand(&cobra.Command{
+		Use:   "undo <session.json>",
+		Short: "Drops the last step of a session",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := module.LoadSession(args[0])
+			if err != nil {
+				return verror(internalError, err)
+			}
+			m, err := s.Replay(-1)
+			if err != nil {
+				return verror(internalError, err)
+			}
+			defer m.Cleanup()
+			if err := m.Undo(); err != nil {
+				return verror(internalError, err)
+			}
+			return m.SaveSession(args[0], s.Args)
+		},
+	})
+
+	historyCmd.AddCommand(&cobra.Command{
+		Use:   "export <session.json>",
+		Short: "Writes the mutated module of a session to the output file",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if rootFlags.outputFn == "" {
+				return errors.New("no output file given, use -o")
+			}
+			m, err := replaySession(args[0])
+			if err != nil {
+				return err
+			}
+			defer m.Cleanup()
+			if err := saveOutput(m); err != nil {
+				return verror(internalError, err)
+			}
+			return nil
+		},
+	})
+
+	historyCmd.PersistentFlags().IntVar(&historyFlags.step, "step", -1,
+		"number of steps to replay (default all)")
+	rootCmd.AddCommand(&historyCmd)
+}
+
+func replaySession(fn string) (*module.History, error) {
+	s, err := module.LoadSession(fn)
+	if err != nil {
+		return nil, verror(internalError, err)
+	}
+	m, err := s.Replay(historyFlags.step)
+	if err != nil {
+		return nil, verror(internalError, err)
+	}
+	return m, nil
+}
+
+func printSession(s module.Session) {
+	logger.Println("== SESSION ===================================")
+	logger.Println()
+	logger.Println("Input")
+	logger.Printf("  %s\n", s.Input)
+	if s.Hash != "" {
+		logger.Printf("  sha256 %s\n", s.Hash)
+	}
+	if len(s.Args) > 0 {
+		logger.Printf("  from %s\n", strings.Join(s.Args, " "))
+	}
+	logger.Println()
+	logger.Println("Config")
+	logger.Printf("  Entry functions : %s\n", strings.Join(s.Config.EntryFunc, ","))
+	logger.Printf("  Skip functions  : %s\n", strings.Join(s.Config.SkipFuncPref, ","))
+	logger.Printf("  Expand          : %v\n", s.Config.Expand)
+	logger.Println()
+	logger.Println("Steps")
+	if len(s.Steps) == 0 {
+		logger.Println("  none")
+	}
+	for i, st := range s.Steps {
+		var muts []string
+		for _, m := range st.Mutations {
+			muts = append(muts, fmt.Sprintf("[%s] %s", selectionFlag(m.Sel), m.Hex))
+		}
+		if len(muts) == 0 {
+			muts = append(muts, "no mutation")
+		}
+		logger.Printf("  %d: %s\n", i+1, strings.Join(muts, ", "))
+	}
+	logger.Println()
+}
+
+// selectionFlag returns the short flag used to give a bitseq of the selection.
+func selectionFlag(sel core.Selection) string {
+	if f, has := bitseqFlags[sel]; has {
+		return f.short
+	}
+	return fmt.Sprint(int(sel))
+}
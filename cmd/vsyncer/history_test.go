@@ -0,0 +1,116 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"vsync/core"
+	"vsync/module"
+	"vsync/tools"
+)
+
+const progSession = `
+@x = dso_local global i32 0, align 4
+
+define dso_local i32 @main() {
+  %1 = atomicrmw add i32* @x, i32 1 seq_cst, align 4
+  fence seq_cst
+  ret i32 0
+}
+`
+
+func TestHistorySession(t *testing.T) {
+	f, err := ioutil.TempFile(".", "vsyncer_test.*.ll")
+	assert.Nil(t, err)
+	defer tools.Remove(f.Name())
+	_, err = f.WriteString(progSession)
+	assert.Nil(t, err)
+	assert.Nil(t, f.Close())
+
+	m, err := module.Load(f.Name(), module.DefaultConfig())
+	assert.Nil(t, err)
+	defer m.Cleanup()
+
+	// relax the rmw, then make the fence a release fence
+	steps := []core.Assignment{
+		{Bs: core.MustFromBinString("1100"), Sel: core.SelectionAtomic},
+		{Bs: core.MustFromBinString("01"), Sel: core.SelectionFences},
+	}
+	for _, a := range steps {
+		assert.Nil(t, m.Mutate(a))
+		assert.Nil(t, m.Record())
+	}
+	want := m.Assignment(core.SelectionAtomic).Bs
+	assert.Equal(t, "0100", want.ToBinString())
+
+	session := "test_session.json"
+	assert.Nil(t, m.SaveSession(session, []string{f.Name()}))
+	defer tools.Remove(session)
+
+	s, err := module.LoadSession(session)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(s.Steps))
+	assert.Equal(t, []string{f.Name()}, s.Args)
+
+	// replay all steps
+	r, err := s.Replay(-1)
+	assert.Nil(t, err)
+	assert.True(t, want.Equals(r.Assignment(core.SelectionAtomic).Bs))
+
+	// undo the last step
+	assert.Nil(t, r.Undo())
+	assert.Equal(t, "1100", r.Assignment(core.SelectionAtomic).Bs.ToBinString())
+	assert.Equal(t, 1, len(r.Session().Steps))
+	assert.Nil(t, r.Undo())
+	assert.NotNil(t, r.Undo())
+	r.Cleanup()
+
+	// replay only the first step
+	r, err = s.Replay(1)
+	assert.Nil(t, err)
+	assert.Equal(t, "1100", r.Assignment(core.SelectionAtomic).Bs.ToBinString())
+	r.Cleanup()
+}
+
+func TestHistorySessionPath(t *testing.T) {
+	f, err := ioutil.TempFile(".", "vsyncer_test.*.ll")
+	assert.Nil(t, err)
+	defer tools.Remove(f.Name())
+	_, err = f.WriteString(progSession)
+	assert.Nil(t, err)
+	assert.Nil(t, f.Close())
+
+	m, err := module.Load(f.Name(), module.DefaultConfig())
+	assert.Nil(t, err)
+	defer m.Cleanup()
+
+	// the input is saved relative to the session file
+	session := filepath.Join(t.TempDir(), "session.json")
+	assert.Nil(t, m.SaveSession(session, []string{f.Name()}))
+	data, err := ioutil.ReadFile(session)
+	assert.Nil(t, err)
+	var saved module.Session
+	assert.Nil(t, json.Unmarshal(data, &saved))
+	assert.False(t, filepath.IsAbs(saved.Input))
+
+	// and loaded as absolute path
+	input, err := filepath.Abs(f.Name())
+	assert.Nil(t, err)
+	s, err := module.LoadSession(session)
+	assert.Nil(t, err)
+	assert.Equal(t, input, s.Input)
+
+	// the inputs given when resuming must match the session
+	_, err = resumeSession(session, []string{"other.ll"})
+	assert.NotNil(t, err)
+	r, err := resumeSession(session, []string{f.Name()})
+	assert.Nil(t, err)
+	r.Cleanup()
+}
@@ -0,0 +1,265 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"vsync/core"
+	"vsync/module"
+	"vsync/optimizer"
+	"vsync/tools"
+)
+
+// htmlOrdering maps orderings to the short names and CSS classes of the
+// report. The classes follow the colors of the diff output.
+var htmlOrdering = map[core.Ordering]string{
+	core.SeqCst:  "sc",
+	core.Acquire: "acq",
+	core.Release: "rel",
+	core.Relaxed: "rlx",
+}
+
+var htmlKind = map[core.AtomicOp]string{
+	core.Fence:   "fence",
+	core.RMW:     "rmw",
+	core.Load:    "load",
+	core.Store:   "store",
+	core.Cmpxchg: "cmpxchg",
+}
+
+type (
+	htmlReport struct {
+		Input      string
+		Date       string
+		Summary    []module.SummarySection
+		Files      []htmlFile
+		Iterations []optimizer.Iteration
+		Counts     []htmlCount
+		Times      []optimizer.TimeStat
+		Elapsed    time.Duration
+	}
+	htmlFile struct {
+		Name  string
+		Error string
+		Lines []htmlLine
+	}
+	htmlLine struct {
+		Num  int
+		Text string
+		Ops  []htmlOp
+	}
+	htmlOp struct {
+		Kind   string
+		Before htmlOrder
+		After  htmlOrder
+	}
+	// htmlOrder is the ordering of an operation, "na" if not atomic and
+	// "none" if the operation is not in the module.
+	htmlOrder struct {
+		Name  string
+		Class string
+	}
+	htmlCount struct {
+		Name  string
+		Count int
+	}
+)
+
+// writeHTMLReport writes a self-contained HTML report of an optimization run
+// with the summary, the tracked operations in the source files, and the
+// iterations and timings of the driver.
+func writeHTMLReport(fn, input string, m *module.History, sts *optimizer.Stats) error {
+	report := htmlReport{
+		Input:      input,
+		Date:       time.Now().Format(dateTime),
+		Summary:    m.Summary(),
+		Files:      htmlFiles(m.InitialOperations(), m.Operations()),
+		Iterations: sts.Iterations(),
+		Times:      sts.Times(),
+		Elapsed:    sts.Elapsed(),
+	}
+	for t := optimizer.Success; t <= optimizer.PrunedInvalid; t++ {
+		report.Counts = append(report.Counts, htmlCount{Name: fmt.Sprint(t), Count: sts.Count(t)})
+	}
+
+	fp, err := os.OpenFile(tools.FromSlash(fn), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fileMode)
+	if err != nil {
+		return err
+	}
+	if err := report.write(fp); err != nil {
+		_ = fp.Close()
+		return err
+	}
+	return fp.Close()
+}
+
+func (r htmlReport) write(w io.Writer) error {
+	return htmlTemplate.Execute(w, r)
+}
+
+// htmlFiles returns the source files of the operations with each operation
+// attached to its line. The operations before and after are paired by id.
+func htmlFiles(before, after []module.Operation) []htmlFile {
+	type pair struct {
+		loc  module.Loc
+		kind core.AtomicOp
+		b, a *module.Operation
+	}
+	var (
+		pairs = make(map[int]*pair)
+		ids   []int
+	)
+	get := func(op module.Operation) *pair {
+		p, ok := pairs[op.ID]
+		if !ok {
+			p = &pair{loc: op.Loc, kind: op.Kind}
+			pairs[op.ID] = p
+			ids = append(ids, op.ID)
+		}
+		return p
+	}
+	for i := range before {
+		get(before[i]).b = &before[i]
+	}
+	for i := range after {
+		get(after[i]).a = &after[i]
+	}
+	sort.Ints(ids)
+
+	var (
+		names []string
+		ops   = make(map[string]map[int][]htmlOp)
+	)
+	for _, id := range ids {
+		p := pairs[id]
+		if p.loc.Filename == "" || p.loc.Line <= 0 {
+			continue
+		}
+		if ops[p.loc.Filename] == nil {
+			ops[p.loc.Filename] = make(map[int][]htmlOp)
+			names = append(names, p.loc.Filename)
+		}
+		line := int(p.loc.Line)
+		ops[p.loc.Filename][line] = append(ops[p.loc.Filename][line], htmlOp{
+			Kind:   htmlKind[p.kind],
+			Before: htmlOrderOf(p.b),
+			After:  htmlOrderOf(p.a),
+		})
+	}
+	sort.Strings(names)
+
+	var files []htmlFile
+	for _, name := range names {
+		files = append(files, htmlSource(name, ops[name]))
+	}
+	return files
+}
+
+func htmlOrderOf(op *module.Operation) htmlOrder {
+	switch {
+	case op == nil:
+		return htmlOrder{Name: "none", Class: "none"}
+	case !op.Atomic:
+		return htmlOrder{Name: "na", Class: "na"}
+	default:
+		name := htmlOrdering[op.Ordering]
+		return htmlOrder{Name: name, Class: name}
+	}
+}
+
+// htmlSource reads a source file and attaches the operations to its lines.
+// If the file cannot be read, only the lines with operations are listed.
+func htmlSource(name string, ops map[int][]htmlOp) htmlFile {
+	file := htmlFile{Name: name}
+	fp, err := os.Open(tools.FromSlash(name))
+	if err != nil {
+		file.Error = fmt.Sprintf("could not read source file: %v", err)
+		var lines []int
+		for l := range ops {
+			lines = append(lines, l)
+		}
+		sort.Ints(lines)
+		for _, l := range lines {
+			file.Lines = append(file.Lines, htmlLine{Num: l, Ops: ops[l]})
+		}
+		return file
+	}
+	defer fp.Close()
+
+	scanner := bufio.NewScanner(fp)
+	for n := 1; scanner.Scan(); n++ {
+		file.Lines = append(file.Lines, htmlLine{Num: n, Text: scanner.Text(), Ops: ops[n]})
+	}
+	if err := scanner.Err(); err != nil {
+		file.Error = fmt.Sprintf("could not read source file: %v", err)
+	}
+	return file
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>vsyncer report: {{.Input}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+h1, h2, h3 { font-weight: normal; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 2px 8px; text-align: right; }
+th { background: #f0f0f0; }
+td.name, th.name { text-align: left; }
+.src { font-family: monospace; white-space: pre; border: 1px solid #ccc; }
+.src div { padding: 0 4px; }
+.src div.op { background: #fff8dc; }
+.num { display: inline-block; width: 4em; color: #888; text-align: right; margin-right: 1em; }
+.badge { font-size: 85%; margin-left: 1em; padding: 0 4px; border-radius: 3px; background: #eee; }
+.sc { color: #008b8b; } .acq { color: #b8860b; } .rel { color: #008000; }
+.rlx { color: #d00000; } .na { color: #0000cd; } .none { color: #888; }
+.error { color: #d00000; }
+.mono { font-family: monospace; }
+</style>
+</head>
+<body>
+<h1>vsyncer report: {{.Input}}</h1>
+<p>Generated {{.Date}}</p>
+
+<h2>Summary</h2>
+{{range .Summary}}<h3>{{.Title}}</h3>
+<table>
+{{range .Rows}}<tr><td class="name">{{.Name}}</td>{{range .Values}}<td class="mono">{{.}}</td>{{end}}</tr>
+{{end}}</table>
+{{end}}
+<h2>Source files</h2>
+{{range .Files}}<h3>{{.Name}}</h3>
+{{with .Error}}<p class="error">{{.}}</p>{{end}}
+<div class="src">
+{{range .Lines}}<div{{if .Ops}} class="op"{{end}}><span class="num">{{.Num}}</span>{{.Text}}{{range .Ops}}<span class="badge">{{.Kind}} <span class="{{.Before.Class}}">{{.Before.Name}}</span> &rarr; <span class="{{.After.Class}}">{{.After.Name}}</span></span>{{end}}</div>
+{{end}}</div>
+{{else}}<p>No source locations available.</p>
+{{end}}
+<h2>Iterations</h2>
+<table>
+<tr><th>#</th><th class="name">Bitseq</th><th class="name">Status</th><th>Time</th></tr>
+{{range $i, $it := .Iterations}}<tr><td>{{$i}}</td><td class="name mono">{{$it.Bitseq}}</td><td class="name">{{$it.Status}}{{if $it.Recheck}} (recheck){{end}}</td><td>{{$it.Elapsed}}</td></tr>
+{{end}}</table>
+
+<h2>Stats</h2>
+<p>Total time: {{.Elapsed}}</p>
+<table>
+{{range .Counts}}<tr><td class="name">{{.Name}}</td><td>{{.Count}}</td></tr>
+{{end}}</table>
+<table>
+<tr><th class="name">Tag</th><th>Mean</th><th>SD</th><th>Count</th></tr>
+{{range .Times}}<tr><td class="name">{{.Tag}}</td><td>{{.Mean}}</td><td>{{.SD}}</td><td>{{.Count}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
@@ -0,0 +1,57 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"vsync/checker"
+	"vsync/core"
+	"vsync/module"
+	"vsync/optimizer"
+)
+
+func TestHTMLReport(t *testing.T) {
+	loc := module.Loc{Filename: "missing/lock.c", Line: 7, Column: 12}
+	before := []module.Operation{
+		{ID: 1, Kind: core.RMW, Atomic: true, Ordering: core.SeqCst, Loc: loc},
+		{ID: 2, Kind: core.Load, Loc: module.Loc{Filename: "missing/lock.c", Line: 3}},
+	}
+	after := []module.Operation{
+		{ID: 1, Kind: core.RMW, Atomic: true, Ordering: core.Release, Loc: loc},
+		{ID: 2, Kind: core.Load, Loc: module.Loc{Filename: "missing/lock.c", Line: 3}},
+		{ID: 3, Kind: core.Fence, Atomic: true, Ordering: core.SeqCst, Loc: loc},
+	}
+	files := htmlFiles(before, after)
+	assert.Len(t, files, 1)
+	assert.NotEmpty(t, files[0].Error)
+
+	// without the source only the lines with operations are listed
+	lines := files[0].Lines
+	assert.Len(t, lines, 2)
+	assert.Equal(t, 3, lines[0].Num)
+	assert.Equal(t, []htmlOp{{Kind: "load", Before: htmlOrder{"na", "na"}, After: htmlOrder{"na", "na"}}}, lines[0].Ops)
+	assert.Equal(t, []htmlOp{
+		{Kind: "rmw", Before: htmlOrder{"sc", "sc"}, After: htmlOrder{"rel", "rel"}},
+		{Kind: "fence", Before: htmlOrder{"none", "none"}, After: htmlOrder{"sc", "sc"}},
+	}, lines[1].Ops)
+
+	report := htmlReport{
+		Input: "lock.c",
+		Files: files,
+		Iterations: []optimizer.Iteration{
+			{Bitseq: core.MustFromBinString("01"), Status: checker.CheckOK, Elapsed: time.Second},
+		},
+	}
+	var sb strings.Builder
+	assert.Nil(t, report.write(&sb))
+	html := sb.String()
+	assert.Contains(t, html, "<title>vsyncer report: lock.c</title>")
+	assert.Contains(t, html, `<span class="sc">sc</span> &rarr; <span class="rel">rel</span>`)
+	assert.Contains(t, html, "<td>1s</td>")
+}
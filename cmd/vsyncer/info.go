@@ -6,6 +6,7 @@ package main
 import (
 	"github.com/spf13/cobra"
 
+	"vsync/core"
 	"vsync/logger"
 	"vsync/module"
 	"vsync/tools"
@@ -19,14 +20,14 @@ func init() {
 
 		DisableFlagsInUseLine: true,
 
-		RunE: func(cmd *cobra.Command, args []string) error {
+		RunE: withTarget(func(cmd *cobra.Command, args []string) error {
 			var (
 				outputGen = newOutputGenerator(args)
 				fn        = outputGen("")
 			)
 
 			return Info(fn, args)
-		},
+		}),
 	}
 
 	rootCmd.AddCommand(&infoCmd)
@@ -34,10 +35,10 @@ func init() {
 
 // Info compiles input, analyzes result, and prints summary.
 func Info(fn string, args []string) error {
-	if hasToCompile(args) {
-		if err := Compile(fn, args...); err != nil {
-			return err
-		}
+	fn, remove, err := compileConditional(fn, args)
+	if err != nil {
+		return err
+	} else if remove {
 		defer tools.Remove(fn)
 	}
 	logger.Debugf("Info %s", fn)
@@ -48,13 +49,23 @@ func Info(fn string, args []string) error {
 	}
 	defer m.Cleanup()
 	m.PrintSummary()
-	return nil
+	m.PrintThreads()
+	return m.PrintUnreachable()
+}
+
+// insertFences returns whether candidate fences should be added to the
+// module, ie, when a bitseq for them is given or optimize inserts fences.
+func insertFences() bool {
+	return bitseqFlags[core.SelectionInsertions].value != "" || optimizeFlags.insertFences
 }
 
 func moduleConfig() module.Config {
 	return module.Config{
 		EntryFunc:    rootFlags.entryFunc,
 		SkipFuncPref: rootFlags.skipFunc,
+		PinFunc:      rootFlags.pinFunc,
+		PinLoc:       rootFlags.pinLoc,
 		Expand:       rootFlags.expand,
+		InsertFences: insertFences(),
 	}
 }
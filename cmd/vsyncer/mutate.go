@@ -5,6 +5,8 @@ package main
 
 import (
 	"fmt"
+	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -31,14 +33,16 @@ var orderSelection = []core.Selection{
 	core.SelectionAtomic,
 	core.SelectionRMWs,
 	core.SelectionFences,
+	core.SelectionInsertions,
 }
 
 var bitseqFlags = map[core.Selection]*bitseqFlag{
-	core.SelectionLoads:  {"L", "loads", "", core.Bitseq{}},
-	core.SelectionStores: {"S", "stores", "", core.Bitseq{}},
-	core.SelectionAtomic: {"A", "atomics", "", core.Bitseq{}},
-	core.SelectionRMWs:   {"X", "rmws", "", core.Bitseq{}},
-	core.SelectionFences: {"F", "fences", "", core.Bitseq{}},
+	core.SelectionLoads:      {"L", "loads", "", core.Bitseq{}},
+	core.SelectionStores:     {"S", "stores", "", core.Bitseq{}},
+	core.SelectionAtomic:     {"A", "atomics", "", core.Bitseq{}},
+	core.SelectionRMWs:       {"X", "rmws", "", core.Bitseq{}},
+	core.SelectionFences:     {"F", "fences", "", core.Bitseq{}},
+	core.SelectionInsertions: {"I", "insertions", "", core.Bitseq{}},
 }
 
 func addMutateFlags(flags *pflag.FlagSet) {
@@ -48,56 +52,158 @@ func addMutateFlags(flags *pflag.FlagSet) {
 	flags.SetInterspersed(false)
 }
 
+const mutateDoc = `
+Mutates the input file according to the given bitseqs and writes the result to
+the output file given with -o.
+
+With --session, the input, configuration and mutations are recorded in a
+session file. If the session file exists, its mutations are replayed first and
+the new mutations are appended to it, in which case no input file is needed;
+input files that are given must produce the input of the session.
+See 'vsyncer history' to inspect and replay sessions.
+`
+
+var mutateFlags struct {
+	session string
+}
+
 var mutateCmd = cobra.Command{
 	Use:   "mutate [flags] <input.ll>",
 	Short: "Mutate input file given a bitseq",
-	Args:  IsArgsn,
+	Long:  mutateDoc,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if module.SessionExists(mutateFlags.session) {
+			return nil
+		}
+		return IsArgsn(cmd, args)
+	},
 
 	DisableFlagsInUseLine: true,
 
-	RunE: func(cmd *cobra.Command, args []string) error {
+	RunE: withTarget(func(cmd *cobra.Command, args []string) error {
 		var (
-			outputGen = newOutputGenerator(args)
-			fn        = outputGen("")
+			m   *module.History
+			err error
 		)
-
-		if hasToCompile(args) {
-			if err := Compile(fn, args...); err != nil {
-				return err
-			}
-			defer tools.Remove(fn)
+		if module.SessionExists(mutateFlags.session) {
+			m, err = resumeSession(mutateFlags.session, args, liftSelection, orderSelection)
+		} else {
+			m, err = mutateArgs(args)
 		}
-
-		m, err := mutate(fn, liftSelection, orderSelection)
 		if err != nil {
 			return err
 		}
 		defer m.Cleanup()
 
-		ofn := rootFlags.outputFn
-		logger.Debugf("Output file '%s'", ofn)
-		tools.Dump(m, ofn)
+		if err := saveOutput(m); err != nil {
+			return verror(internalError, err)
+		}
+		if err := saveChanges(m); err != nil {
+			return verror(internalError, err)
+		}
 		m.PrintSummary()
 		return m.PrintDiff()
-	},
+	}),
+}
+
+// mutateArgs compiles the arguments if necessary and mutates the resulting
+// module. If a session is given, the compiled module is kept as input of the
+// session.
+func mutateArgs(args []string) (*module.History, error) {
+	var (
+		outputGen = newOutputGenerator(args)
+		fn        = outputGen("")
+		session   = mutateFlags.session
+	)
+
+	switch {
+	case session != "" && hasToCompile(args):
+		// the compiled module is the input of the session
+		if err := Compile(fn, args...); err != nil {
+			return nil, err
+		}
+	case session == "":
+		var (
+			remove bool
+			err    error
+		)
+		fn, remove, err = compileConditional(fn, args)
+		if err != nil {
+			return nil, err
+		} else if remove {
+			defer tools.Remove(fn)
+		}
+	}
+
+	m, err := mutate(fn, liftSelection, orderSelection)
+	if err != nil {
+		return nil, err
+	}
+	if session != "" {
+		if err := m.SaveSession(session, args); err != nil {
+			m.Cleanup()
+			return nil, verror(internalError, err)
+		}
+	}
+	return m, nil
+}
+
+// resumeSession replays a session, applies the mutations given as flags and
+// saves the extended session. If input arguments are given, they have to
+// produce the input module of the session.
+func resumeSession(fn string, args []string, stages ...[]core.Selection) (*module.History, error) {
+	s, err := module.LoadSession(fn)
+	if err != nil {
+		return nil, verror(internalError, err)
+	}
+	if len(args) > 0 {
+		input, err := filepath.Abs(tools.FromSlash(newOutputGenerator(args)("")))
+		if err != nil {
+			return nil, verror(internalError, err)
+		}
+		if tools.ToSlash(input) != s.Input {
+			return nil, fmt.Errorf("input '%s' differs from the input '%s' of session '%s'",
+				strings.Join(args, " "), s.Input, fn)
+		}
+	}
+	m, err := s.Replay(-1)
+	if err != nil {
+		return nil, verror(internalError, err)
+	}
+	if err := applyMutations(m, stages...); err != nil {
+		m.Cleanup()
+		return nil, err
+	}
+	if err := m.SaveSession(fn, s.Args); err != nil {
+		m.Cleanup()
+		return nil, verror(internalError, err)
+	}
+	return m, nil
 }
 
 func init() {
 	rootCmd.AddCommand(&mutateCmd)
 	addMutateFlags(mutateCmd.PersistentFlags())
+	addSarifFlag(mutateCmd.PersistentFlags())
+	mutateCmd.Flags().StringVar(&mutateFlags.session, "session", "",
+		"session file recording the mutations")
 }
 
 func mutate(fn string, stages ...[]core.Selection) (*module.History, error) {
-	var (
-		cfg = moduleConfig()
-		err error
-	)
-
-	m, err := module.Load(fn, cfg)
+	m, err := module.Load(fn, moduleConfig())
 	if err != nil {
 		return nil, verror(internalError, err)
 	}
+	if err := applyMutations(m, stages...); err != nil {
+		m.Cleanup()
+		return nil, err
+	}
+	return m, nil
+}
 
+// applyMutations mutates and records the module with the bitseqs given as flags.
+func applyMutations(m *module.History, stages ...[]core.Selection) error {
+	var err error
 	for _, sgroup := range stages {
 		for _, sel := range sgroup {
 			bitseqSel := bitseqFlags[sel]
@@ -107,18 +213,18 @@ func mutate(fn string, stages ...[]core.Selection) (*module.History, error) {
 			a := m.Assignment(sel)
 			a.Bs, err = core.ParseBitseq(bitseqSel.value, a.Bs.Length())
 			if err != nil {
-				return nil, verror(internalError, err)
+				return verror(internalError, err)
 			}
 			logger.Debugf("Applying assignment %v", a)
 
 			if err := m.Mutate(a); err != nil {
-				return nil, verror(internalError, err)
+				return verror(internalError, err)
 			}
 
 			if err := m.Record(); err != nil {
-				return nil, verror(internalError, err)
+				return verror(internalError, err)
 			}
 		}
 	}
-	return m, nil
+	return nil
 }
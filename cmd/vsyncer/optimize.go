@@ -5,7 +5,10 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -23,8 +26,13 @@ const cpuFactor = 2
 var optimizeCmd = cobra.Command{
 	Use:   "optimize [flags] <input.ll|input.c>",
 	Short: "Finds an optimization for input file",
-	Args:  IsArgsn,
-	RunE:  optimizeRun,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if optimizeFlags.manifest != "" {
+			return nil
+		}
+		return IsArgsn(cmd, args)
+	},
+	RunE: withTarget(optimizeRun),
 
 	DisableFlagsInUseLine: true,
 }
@@ -36,6 +44,19 @@ var optimizeFlags = struct {
 	filter       string
 	alpha        float64
 	errorInvalid bool
+	insertFences bool
+	html         string
+	jobs         uint
+	checkpoint   string
+	interval     time.Duration
+	resume       bool
+	all          bool
+	pick         uint
+	harness      bool
+	manifest     string
+	objective    string
+	costModel    string
+	costs        []string
 }{}
 
 func initOptimize() {
@@ -43,30 +64,69 @@ func initOptimize() {
 	flags := optimizeCmd.PersistentFlags()
 	addMutateFlags(flags)
 	addCheckFlags(flags)
-	flags.StringVarP(&optimizeFlags.algorithm, "algorithm", "a", "lr", "optimization algorithm (lr|ddmin)")
+	addSarifFlag(flags)
+	flags.StringVarP(&optimizeFlags.algorithm, "algorithm", "a", "lr", "optimization algorithm (lr|ddmin|hier)")
 	flags.BoolVar(&optimizeFlags.errorInvalid, "error-as-invalid", false, "map checker errors as invalid mutations")
 	flags.BoolVar(&optimizeFlags.adaptive, "adaptive", true, "use adaptive timeout to optimize")
 	flags.DurationVar(&optimizeFlags.timeout, "speculate", 0, "speculate variant correct after given timeout")
 	flags.StringVar(&optimizeFlags.filter, "filter", "rlx", "filter (none/dup/rlx)")
 	flags.Float64Var(&optimizeFlags.alpha, "alpha", 0, "memory alpha for adaptive")
+	flags.BoolVar(&optimizeFlags.insertFences, "insert-fences", false,
+		"insert fences after plain stores and before plain loads and minimize them\ninstead of the orderings of the atomics")
+	flags.StringVar(&optimizeFlags.html, "html", "", "HTML file to write a report of the optimization run to")
+	flags.UintVarP(&optimizeFlags.jobs, "jobs", "j", 1, "number of candidates checked concurrently")
+	flags.StringVar(&optimizeFlags.checkpoint, "checkpoint", "",
+		"file to save the state of the run to and to resume it from")
+	flags.DurationVar(&optimizeFlags.interval, "checkpoint-interval", time.Minute, "minimum time between checkpoints")
+	flags.BoolVar(&optimizeFlags.resume, "resume", false, "continue the run saved in the --checkpoint file")
+	flags.BoolVar(&optimizeFlags.all, "all", false, "enumerate all maximally relaxed solutions")
+	flags.UintVar(&optimizeFlags.pick, "pick", 1, "solution of --all to output")
+	flags.BoolVar(&optimizeFlags.harness, "harness", false,
+		"optimize the library used by the inputs, each input being a separate harness")
+	flags.StringVar(&optimizeFlags.manifest, "manifest", "", "file with a harness per line as in check-all, implies --harness")
+	flags.StringVar(&optimizeFlags.objective, "objective", "bits", "what to minimize (bits|cost)")
+	flags.StringVar(&optimizeFlags.costModel, "cost-model", "",
+		fmt.Sprintf("cost model of the target architecture (%s)\n(default: the memory model if it has one, otherwise uniform)",
+			strings.Join(optimizer.CostModels(), "|")))
+	flags.StringSliceVar(&optimizeFlags.costs, "cost", nil,
+		"override the cost of an ordering as kind.ordering=cost, eg, fence.seq_cst=50")
 }
 
-func compileConditional(fn string, args []string) (bool, error) {
+// compileConditional compiles the arguments into the work directory if
+// necessary. It returns the module to analyze and whether it was compiled.
+func compileConditional(fn string, args []string) (string, bool, error) {
 	if !hasToCompile(args) {
-		return false, nil
+		return fn, false, nil
+	}
+	wfn, err := tools.WorkPath(filepath.Base(fn))
+	if err != nil {
+		return "", false, err
 	}
-	return true, Compile(fn, args...)
+	return wfn, true, Compile(wfn, args...)
 }
 
 func optimizeRun(_ *cobra.Command, args []string) error {
+	if optimizeFlags.resume && optimizeFlags.checkpoint == "" {
+		return fmt.Errorf("--resume requires --checkpoint")
+	}
+	if optimizeFlags.all && optimizeFlags.checkpoint != "" {
+		return fmt.Errorf("--all cannot be combined with --checkpoint or --resume")
+	}
+	if optimizeFlags.pick == 0 {
+		return fmt.Errorf("--pick starts at 1")
+	}
+	if optimizeFlags.harness || optimizeFlags.manifest != "" {
+		return optimizeHarnessRun(args)
+	}
 
 	var (
 		outputGen = newOutputGenerator(args)
 		fn        = outputGen("")
-		mm        = checker.ParseMemoryModel(checkFlags.memoryModel)
+		sts       = optimizer.NewStats()
 	)
 
-	if remove, err := compileConditional(fn, args); err != nil {
+	fn, remove, err := compileConditional(fn, args)
+	if err != nil {
 		return err
 	} else if remove {
 		defer tools.Remove(fn)
@@ -77,28 +137,94 @@ func optimizeRun(_ *cobra.Command, args []string) error {
 		return err
 	}
 	defer m.Cleanup()
+	warnUnreachable(m)
 
+	sel := core.SelectionAtomic
+	if optimizeFlags.insertFences {
+		// start with seq_cst fences at all candidate points
+		sel = core.SelectionInsertions
+		if bitseqFlags[sel].value == "" {
+			a := m.Assignment(sel)
+			a.Bs = a.Bs.SetRange(0, a.Bs.Length()-1)
+			if err := m.Mutate(a); err != nil {
+				return verror(internalError, err)
+			}
+		}
+	}
 	if err := m.Record(); err != nil {
 		return verror(internalError, err)
 	}
 
-	chkr, err := newChecker(checker.ParseID(rootFlags.checker), mm)
+	chkr, err := newModelsChecker(checker.ParseID(rootFlags.checker), checkFlags.memoryModel, sts)
 	if err != nil {
 		return err
 	}
 
 	cfg := newDriverConfig()
-	sts := optimizer.NewStats()
-	sel := core.SelectionAtomic
+	if cfg.Costs, err = newCostModel(); err != nil {
+		return err
+	}
 	ia := m.Assignment(sel)
+	cfg.Pinned = m.Pinned(sel)
+	if cfg.Strategy == optimizer.Hier {
+		cfg.Groups = m.Groups(sel)
+	}
+	if sel.Binary() {
+		cfg.Kinds = m.Kinds(sel)
+	} else if cfg.Objective == optimizer.ObjectiveCost {
+		return fmt.Errorf("--objective cost requires a selection of memory orderings")
+	}
 	d := optimizer.NewDriver(cfg, chkr, sts)
-	s := d.Run(context.Background(), m, sel)
+	s, err := optimizeModule(d, m, sel, func(bs core.Bitseq) (module.BarrierCount, error) {
+		return m.BarrierCount(core.Assignment{Bs: bs, Sel: sel})
+	})
+	if err != nil {
+		return err
+	}
 	defer logger.Println(sts)
 
-	return evaluateOptimizeResult(s, chkr, m, ia)
+	if err := evaluateOptimizeResult(s, chkr, m, ia, costEstimate(cfg)); err != nil {
+		return err
+	}
+	if optimizeFlags.html != "" {
+		if err := writeHTMLReport(optimizeFlags.html, args[0], m, sts); err != nil {
+			return verror(internalError, err)
+		}
+	}
+	if err := saveOutput(m); err != nil {
+		return verror(internalError, err)
+	}
+	if err := saveChanges(m); err != nil {
+		return verror(internalError, err)
+	}
+	return nil
 }
 
-func evaluateOptimizeResult(s optimizer.Solution, chkr checker.Tool, m *module.History, ia core.Assignment) error {
+// optimizeModule runs the driver on the module, or enumerates the solutions
+// with --all and returns the picked one. count returns the number of
+// operations per memory ordering of a bitseq for the table of solutions.
+func optimizeModule(d *optimizer.Driver, m optimizer.MutableModule, sel core.Selection,
+	count func(core.Bitseq) (module.BarrierCount, error)) (optimizer.Solution, error) {
+	if optimizeFlags.resume {
+		if err := d.Resume(m, sel); err != nil {
+			return optimizer.Solution{}, verror(internalError, fmt.Errorf("cannot resume: %v", err))
+		}
+	}
+	if !optimizeFlags.all {
+		return d.Run(context.Background(), m, sel), nil
+	}
+	sol := d.Enumerate(context.Background(), m, sel)
+	if err := printEnumeration(count, sol); err != nil {
+		return optimizer.Solution{}, verror(internalError, err)
+	}
+	if int(optimizeFlags.pick) > len(sol) {
+		return optimizer.Solution{}, fmt.Errorf("cannot pick solution %d of %d", optimizeFlags.pick, len(sol))
+	}
+	return sol[optimizeFlags.pick-1], nil
+}
+
+func evaluateOptimizeResult(s optimizer.Solution, chkr checker.Tool, m *module.History, ia core.Assignment,
+	cost func(core.Bitseq) int) error {
 	// if the solution is the same as the input, we should check if the
 	// user hasn't given a rather incorrect bs:
 	if s.Bitseq().Equals(ia.Bs) {
@@ -117,14 +243,14 @@ func evaluateOptimizeResult(s optimizer.Solution, chkr checker.Tool, m *module.H
 		switch r.Status {
 		case checker.CheckOK:
 			logger.Println("OK     ", elapsed)
-			printSolutions(m, ia.Bs, s, true)
+			printSolutions(m, ia, s, true, cost)
 
 		default:
 			logger.Println("FAIL   ", elapsed)
-			printSolutions(m, ia.Bs, s, false)
+			printSolutions(m, ia, s, false, cost)
 		}
 	} else {
-		printSolutions(m, ia.Bs, s, true)
+		printSolutions(m, ia, s, true, cost)
 	}
 
 	return nil
@@ -135,6 +261,11 @@ func newDriverConfig() optimizer.DriverConfig {
 		ErrorAsInvalid: optimizeFlags.errorInvalid,
 		Alpha:          optimizeFlags.alpha,
 		BitsPerOp:      2,
+		Jobs:           int(optimizeFlags.jobs),
+		Checker:        rootFlags.checker + " " + checkFlags.memoryModel,
+
+		Checkpoint:         optimizeFlags.checkpoint,
+		CheckpointInterval: optimizeFlags.interval,
 	}
 	if optimizeFlags.adaptive {
 		cfg.Tau = 1 * time.Millisecond
@@ -154,11 +285,22 @@ func newDriverConfig() optimizer.DriverConfig {
 		logger.Fatalf("unknown filter type %v", optimizeFlags.filter)
 	}
 
+	switch optimizeFlags.objective {
+	case "bits":
+		cfg.Objective = optimizer.ObjectiveBits
+	case "cost":
+		cfg.Objective = optimizer.ObjectiveCost
+	default:
+		logger.Fatalf("unknown objective %v", optimizeFlags.objective)
+	}
+
 	switch optimizeFlags.algorithm {
 	case "lr":
 		cfg.Strategy = optimizer.LR
 	case "ddmin":
 		cfg.Strategy = optimizer.DDmin
+	case "hier":
+		cfg.Strategy = optimizer.Hier
 	default:
 		logger.Fatal("invalid algorithm", optimizeFlags.algorithm)
 	}
@@ -166,10 +308,81 @@ func newDriverConfig() optimizer.DriverConfig {
 	return cfg
 }
 
-func printSolutions(m *module.History, initial core.Bitseq, s optimizer.Solution, correct bool) {
+// newModelsChecker returns the checker of a comma-separated list of memory
+// models. With several memory models, a candidate is correct only if it is
+// correct with all of them and the time of each model is added to the stats.
+func newModelsChecker(cid checker.ID, models string, sts *optimizer.Stats) (checker.Tool, error) {
+	names := strings.Split(models, ",")
+	if len(names) == 1 {
+		return newChecker(cid, checker.ParseMemoryModel(models))
+	}
+	var tools []checker.Tool
+	for i, name := range names {
+		names[i] = strings.TrimSpace(name)
+		c, err := newChecker(cid, checker.ParseMemoryModel(names[i]))
+		if err != nil {
+			return nil, err
+		}
+		tools = append(tools, c)
+	}
+	multi := checker.NewMulti(names, tools)
+	multi.OnResult = func(name string, _ checker.CheckResult, elapsed time.Duration) {
+		sts.AddTime("model "+name, elapsed)
+	}
+	return multi, nil
+}
+
+// costModelName returns the cost model given with --cost-model or the one of
+// the memory model.
+func costModelName() string {
+	if optimizeFlags.costModel != "" {
+		return optimizeFlags.costModel
+	}
+	name := checkFlags.memoryModel
+	for _, cm := range optimizer.CostModels() {
+		if cm == name {
+			return name
+		}
+	}
+	return "uniform"
+}
+
+// newCostModel returns the cost model with the costs given with --cost.
+func newCostModel() (optimizer.CostModel, error) {
+	cm, err := optimizer.NewCostModel(costModelName())
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range optimizeFlags.costs {
+		if err := cm.Set(c); err != nil {
+			return nil, err
+		}
+	}
+	return cm, nil
+}
+
+// costEstimate returns the estimated cost of a bitseq with the driver
+// configuration, or nil if the kinds of the operations are unknown.
+func costEstimate(cfg optimizer.DriverConfig) func(core.Bitseq) int {
+	if len(cfg.Kinds) == 0 {
+		return nil
+	}
+	return func(bs core.Bitseq) int {
+		return cfg.Costs.Cost(cfg.Kinds, bs)
+	}
+}
+
+func printSolutions(m *module.History, ia core.Assignment, s optimizer.Solution, correct bool,
+	cost func(core.Bitseq) int) {
+	initial := ia.Bs
 
 	logger.Println()
 	m.PrintSummary()
+	if cost != nil {
+		logger.Printf("Estimated cost (%s)\n   before: %d\n   after : %d\n", costModelName(),
+			cost(initial), cost(s.Bitseq()))
+		logger.Println()
+	}
 	if s.Bitseq().Equals(initial) && correct {
 		logger.Printf("Result\n   No optimization found!\n")
 		logger.Println()
@@ -180,7 +393,7 @@ func printSolutions(m *module.History, initial core.Bitseq, s optimizer.Solution
 		if err := m.Forget(); err != nil {
 			logger.Fatal(err)
 		}
-		if err := m.Mutate(core.Assignment{Bs: s.Bitseq(), Sel: core.SelectionAtomic}); err != nil {
+		if err := m.Mutate(core.Assignment{Bs: s.Bitseq(), Sel: ia.Sel}); err != nil {
 			logger.Fatal(err)
 		}
 
@@ -193,6 +406,30 @@ func printSolutions(m *module.History, initial core.Bitseq, s optimizer.Solution
 	logger.Println("== ITERATION STATS ===========================")
 }
 
+// printEnumeration prints the solutions found with --all and their number of
+// operations per memory ordering.
+func printEnumeration(count func(core.Bitseq) (module.BarrierCount, error), sol []optimizer.Solution) error {
+	logger.Println()
+	logger.Println("== SOLUTIONS =================================")
+	logger.Println()
+	logger.Printf("  %4s  %-24s  %6s  %7s  %7s  %7s\n", "#", "Bitseq", "SeqCst", "Release", "Acquire", "Relaxed")
+	for i, s := range sol {
+		bc, err := count(s.Bitseq())
+		if err != nil {
+			return err
+		}
+		mark := " "
+		if uint(i+1) == optimizeFlags.pick {
+			mark = "*"
+		}
+		logger.Printf("%s %4d  %-24v  %6d  %7d  %7d  %7d\n", mark, i+1, "0x"+s.Bitseq().ToHexString(),
+			bc.SeqCst, bc.Release, bc.Acquire, bc.Relaxed)
+	}
+	logger.Println()
+	logger.Printf("Found %d solutions, the solution marked with * is used (see --pick)\n", len(sol))
+	return nil
+}
+
 func defaultInstances(nb uint) uint {
 	if nb != 0 {
 		return nb
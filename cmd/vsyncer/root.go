@@ -28,25 +28,10 @@ var rootCmd = cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Println("run 'vsyncer -h' for help")
 	},
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
-		switch rootFlags.log {
-		case "INFO":
-			logger.SetLevel(logger.INFO)
-		case "WARN":
-			logger.SetLevel(logger.WARN)
-		default:
-			logger.SetLevel(logger.ERROR)
-		}
-		if rootFlags.debug {
-			logger.SetLevel(logger.DEBUG)
-		}
-		if rootFlags.quiet {
-			logger.SetFileDescriptor(nil)
-		}
-	},
 }
 
 func init() {
+	rootCmd.PersistentPreRunE = rootPreRun
 	tools.RegEnv("VSYNCER_DEFAULT_CHECKER", "genmc", "Default model checker")
 	tools.RegEnv("VSYNCER_DEFAULT_ENTRY_FUNC", "main", "Default entry functions for analysis")
 	tools.RegEnv("VSYNCER_DEFAULT_SKIP_FUNC", "pthread_,__assert_fail,llvm.,_VERIFIER", "Default prefixes of functions to skip")
@@ -74,11 +59,45 @@ func init() {
 	flags.StringSliceVar(&rootFlags.skipFunc, "skip-func",
 		strings.Split(tools.GetEnv("VSYNCER_DEFAULT_SKIP_FUNC"), ","),
 		"list of function prefixes to skip")
+	flags.StringArrayVar(&rootFlags.fileCflags, "file-cflags", nil,
+		"compilation flags for a single input file (file=flags)")
+	flags.StringSliceVar(&rootFlags.pinFunc, "pin-func", nil,
+		"list of functions whose operations keep their ordering")
+	flags.StringSliceVar(&rootFlags.pinLoc, "pin-loc", nil,
+		"list of source locations (file:line) whose operations keep their ordering")
+	flags.StringVar(&rootFlags.workDir, "work-dir", tools.GetEnv("VSYNCER_WORK_DIR"),
+		"directory where the work directory with temporary files is created")
+	flags.BoolVar(&rootFlags.keepArtifacts, "keep-artifacts", false,
+		"keep the temporary files of the run for debugging")
 
 	rootCmd.SetHelpCommand(&cobra.Command{Hidden: true})
 	initOptimize()
 }
 
+// rootPreRun applies the project configuration and the root flags before
+// running any command.
+func rootPreRun(cmd *cobra.Command, _ []string) error {
+	if err := applyConfig(cmd); err != nil {
+		return err
+	}
+	switch rootFlags.log {
+	case "INFO":
+		logger.SetLevel(logger.INFO)
+	case "WARN":
+		logger.SetLevel(logger.WARN)
+	default:
+		logger.SetLevel(logger.ERROR)
+	}
+	if rootFlags.debug {
+		logger.SetLevel(logger.DEBUG)
+	}
+	if rootFlags.quiet {
+		logger.SetFileDescriptor(nil)
+	}
+	tools.SetWorkDir(rootFlags.workDir, rootFlags.keepArtifacts)
+	return nil
+}
+
 var reExitStatus = regexp.MustCompile("^exit status [0-9]+$")
 
 func getCheckerID() checker.ID {
@@ -96,6 +115,12 @@ var rootFlags struct {
 
 	expandOnly bool
 	skipFunc   []string
+	pinFunc    []string
+	pinLoc     []string
+	fileCflags []string
+
+	workDir       string
+	keepArtifacts bool
 }
 
 type errCode struct {
@@ -121,7 +146,9 @@ func main() {
 	if !rootFlags.debug {
 		defer handlePanic()
 	}
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.Execute()
+	tools.CleanupWorkDir()
+	if err != nil {
 		var (
 			code = getErrorCode(err)
 			msg  = getErrorMessage(err)
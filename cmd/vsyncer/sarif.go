@@ -0,0 +1,224 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"vsync/checker"
+	"vsync/module"
+	"vsync/tools"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+
+	ruleOrdering = "ordering-change"
+	ruleCheck    = "check-failure"
+)
+
+var sarifFile string
+
+func addSarifFlag(flags *pflag.FlagSet) {
+	flags.StringVar(&sarifFile, "sarif", "", "SARIF file to write the findings to")
+}
+
+// The types below implement the subset of SARIF 2.1.0 used by vsyncer.
+type (
+	sarifLog struct {
+		Schema  string     `json:"$schema"`
+		Version string     `json:"version"`
+		Runs    []sarifRun `json:"runs"`
+	}
+	sarifRun struct {
+		Tool    sarifTool     `json:"tool"`
+		Results []sarifResult `json:"results"`
+	}
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+	sarifDriver struct {
+		Name           string      `json:"name"`
+		Version        string      `json:"version"`
+		InformationURI string      `json:"informationUri"`
+		Rules          []sarifRule `json:"rules"`
+	}
+	sarifRule struct {
+		ID               string       `json:"id"`
+		ShortDescription sarifMessage `json:"shortDescription"`
+	}
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+	sarifResult struct {
+		RuleID    string          `json:"ruleId"`
+		Level     string          `json:"level"`
+		Message   sarifMessage    `json:"message"`
+		Locations []sarifLocation `json:"locations,omitempty"`
+		Fixes     []sarifFix      `json:"fixes,omitempty"`
+	}
+	sarifLocation struct {
+		PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	}
+	sarifPhysicalLocation struct {
+		ArtifactLocation sarifArtifact `json:"artifactLocation"`
+		Region           *sarifRegion  `json:"region,omitempty"`
+	}
+	sarifArtifact struct {
+		URI string `json:"uri"`
+	}
+	sarifRegion struct {
+		StartLine   int64 `json:"startLine"`
+		StartColumn int64 `json:"startColumn,omitempty"`
+		EndColumn   int64 `json:"endColumn,omitempty"`
+	}
+	sarifFix struct {
+		Description     sarifMessage          `json:"description"`
+		ArtifactChanges []sarifArtifactChange `json:"artifactChanges"`
+	}
+	sarifArtifactChange struct {
+		ArtifactLocation sarifArtifact      `json:"artifactLocation"`
+		Replacements     []sarifReplacement `json:"replacements"`
+	}
+	sarifReplacement struct {
+		DeletedRegion   sarifRegion  `json:"deletedRegion"`
+		InsertedContent sarifMessage `json:"insertedContent"`
+	}
+)
+
+type sarifReport struct {
+	results []sarifResult
+}
+
+// addChanges adds a result for each change of the module. Changes of vatomic
+// calls come with a fix replacing the called function.
+func (r *sarifReport) addChanges(changes []module.Change) {
+	for _, c := range changes {
+		res := sarifResult{
+			RuleID:    ruleOrdering,
+			Level:     "note",
+			Message:   sarifMessage{Text: c.Description()},
+			Locations: []sarifLocation{sarifLoc(c.Loc)},
+		}
+		if fn, ok := c.Replacement(); ok && c.Loc.Column > 0 {
+			res.Fixes = []sarifFix{{
+				Description: sarifMessage{Text: c.Description()},
+				ArtifactChanges: []sarifArtifactChange{{
+					ArtifactLocation: sarifArtifact{URI: sarifURI(c.Loc.Filename)},
+					Replacements: []sarifReplacement{{
+						DeletedRegion: sarifRegion{
+							StartLine:   c.Loc.Line,
+							StartColumn: c.Loc.Column,
+							EndColumn:   c.Loc.Column + int64(len(c.Func)),
+						},
+						InsertedContent: sarifMessage{Text: fn},
+					}},
+				}},
+			}}
+		}
+		r.results = append(r.results, res)
+	}
+}
+
+// reOutputLoc matches source locations such as "lock.c:12" or "lock.c:12:5"
+// in the output of the checkers.
+var reOutputLoc = regexp.MustCompile(`([\w./-]+\.(?:c|h|cc|cpp|hpp)):([0-9]+)(?::([0-9]+))?`)
+
+// addFailure adds a result for a failed check with the source locations
+// found in the output of the checker.
+func (r *sarifReport) addFailure(input string, result checker.CheckResult) {
+	res := sarifResult{
+		RuleID:  ruleCheck,
+		Level:   "error",
+		Message: sarifMessage{Text: fmt.Sprintf("check failed with status %v", result.Status)},
+	}
+	seen := make(map[string]bool)
+	for _, m := range reOutputLoc.FindAllStringSubmatch(result.Output, -1) {
+		if seen[m[0]] {
+			continue
+		}
+		seen[m[0]] = true
+		loc := module.Loc{Filename: m[1]}
+		loc.Line, _ = strconv.ParseInt(m[2], 10, 64)
+		if m[3] != "" {
+			loc.Column, _ = strconv.ParseInt(m[3], 10, 64)
+		}
+		res.Locations = append(res.Locations, sarifLoc(loc))
+	}
+	if len(res.Locations) == 0 {
+		res.Locations = []sarifLocation{{
+			PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifact{URI: sarifURI(input)}},
+		}}
+	}
+	if first := strings.TrimSpace(result.Output); first != "" {
+		res.Message.Text += ": " + strings.SplitN(first, "\n", 2)[0]
+	}
+	r.results = append(r.results, res)
+}
+
+func sarifLoc(loc module.Loc) sarifLocation {
+	l := sarifLocation{
+		PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifact{URI: sarifURI(loc.Filename)},
+		},
+	}
+	if loc.Line > 0 {
+		l.PhysicalLocation.Region = &sarifRegion{StartLine: loc.Line, StartColumn: loc.Column}
+	}
+	return l
+}
+
+func sarifURI(fn string) string {
+	return tools.ToSlash(fn)
+}
+
+// saveChanges writes the changes of the module to the SARIF file if given.
+func saveChanges(m *module.History) error {
+	if sarifFile == "" {
+		return nil
+	}
+	var report sarifReport
+	report.addChanges(m.Changes())
+	return report.save(sarifFile)
+}
+
+// save writes the report to the file if given.
+func (r *sarifReport) save(fn string) error {
+	if fn == "" {
+		return nil
+	}
+	results := r.results
+	if results == nil {
+		results = []sarifResult{}
+	}
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           name,
+				Version:        version,
+				InformationURI: "https://github.com/open-s4c/vsyncer",
+				Rules: []sarifRule{
+					{ID: ruleOrdering, ShortDescription: sarifMessage{Text: "Suggested memory ordering change"}},
+					{ID: ruleCheck, ShortDescription: sarifMessage{Text: "Model checker found a violation"}},
+				},
+			}},
+			Results: results,
+		}},
+	}
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(tools.FromSlash(fn), append(data, '\n'), fileMode)
+}
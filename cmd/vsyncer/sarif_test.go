@@ -0,0 +1,62 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"vsync/checker"
+	"vsync/core"
+	"vsync/module"
+	"vsync/tools"
+)
+
+func TestSarifReport(t *testing.T) {
+	f, err := ioutil.TempFile(".", "vsyncer_test.*.sarif")
+	assert.Nil(t, err)
+	assert.Nil(t, f.Close())
+	defer tools.Remove(f.Name())
+
+	var report sarifReport
+	report.addFailure("lock.c", checker.CheckResult{
+		Status: checker.CheckNotSafe,
+		Output: "Error detected: Safety violation!\n" +
+			"[lock.c:12:5] store\n[lock.c:20] load\n[lock.c:12:5] store\n",
+	})
+	report.addChanges([]module.Change{{
+		Loc:            module.Loc{Filename: "lock.c", Line: 7, Column: 3},
+		Func:           "vatomic32_read",
+		Kind:           core.Load,
+		AtomicBefore:   true,
+		AtomicAfter:    true,
+		OrderingBefore: core.SeqCst,
+		OrderingAfter:  core.Acquire,
+	}})
+	assert.Nil(t, report.save(f.Name()))
+
+	data, err := ioutil.ReadFile(f.Name())
+	assert.Nil(t, err)
+	var log sarifLog
+	assert.Nil(t, json.Unmarshal(data, &log))
+	assert.Equal(t, sarifVersion, log.Version)
+	assert.Len(t, log.Runs, 1)
+
+	res := log.Runs[0].Results
+	assert.Len(t, res, 2)
+	assert.Equal(t, ruleCheck, res[0].RuleID)
+	assert.Contains(t, res[0].Message.Text, "Error detected: Safety violation!")
+	assert.Len(t, res[0].Locations, 2)
+	assert.Equal(t, &sarifRegion{StartLine: 20}, res[0].Locations[1].PhysicalLocation.Region)
+
+	assert.Equal(t, ruleOrdering, res[1].RuleID)
+	assert.Equal(t, "replace vatomic32_read with vatomic32_read_acq", res[1].Message.Text)
+	assert.Len(t, res[1].Fixes, 1)
+	rep := res[1].Fixes[0].ArtifactChanges[0].Replacements[0]
+	assert.Equal(t, sarifRegion{StartLine: 7, StartColumn: 3, EndColumn: 17}, rep.DeletedRegion)
+	assert.Equal(t, "vatomic32_read_acq", rep.InsertedContent.Text)
+}
@@ -6,15 +6,19 @@ package main
 import (
 	"fmt"
 	"regexp"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"vsync/logger"
+	"vsync/module"
+	"vsync/tools"
 )
 
-// IsArgsn ensures there are 1 or more arguments
+// IsArgsn ensures there are 1 or more arguments unless a target of the
+// project configuration gives the input files, see inputArgs.
 func IsArgsn(_ *cobra.Command, args []string) error {
-	if len(args) < 1 {
+	if len(args) < 1 && configFlags.target == "" {
 		return fmt.Errorf("no input file specified")
 	}
 	return nil
@@ -24,15 +28,18 @@ var (
 	reIsC     = regexp.MustCompile(`.*\.c$`)
 	reIsCPP   = regexp.MustCompile(`.*\.cpp$`)
 	reIsLL    = regexp.MustCompile(`(.*)\.ll$`)
-	reIsLLOrC = regexp.MustCompile(`(.*)\.(ll|c|C|cpp|cxx)$`)
+	reIsBC    = regexp.MustCompile(`(.*)\.bc$`)
+	reIsIR    = regexp.MustCompile(`(.*)\.(ll|bc)$`)
+	reIsLLOrC = regexp.MustCompile(`(.*)\.(ll|bc|c|C|cpp|cxx)$`)
 )
 
-func onlyLL(args ...string) bool {
+// onlyIR returns true if all arguments are LLVM IR modules, ie, .ll or .bc files.
+func onlyIR(args ...string) bool {
 	if len(args) == 0 {
 		return false
 	}
 	for _, a := range args {
-		if !reIsLL.MatchString(a) {
+		if !reIsIR.MatchString(a) {
 			return false
 		}
 	}
@@ -47,6 +54,8 @@ func base(fn string) string {
 	return reIsLLOrC.ReplaceAllString(fn, "${1}")
 }
 
+// hasToCompile returns true unless the arguments consist of textual LLVM IR
+// modules only. Bitcode modules have to be disassembled before analysis.
 func hasToCompile(args []string) bool {
 	for _, a := range args {
 		if !reIsLL.MatchString(a) {
@@ -56,6 +65,37 @@ func hasToCompile(args []string) bool {
 	return false
 }
 
+// saveOutput writes the module to the output file given with -o, if any.
+// The format of the file depends on its extension, see tools.Save.
+func saveOutput(m fmt.Stringer) error {
+	fn := rootFlags.outputFn
+	if fn == "" {
+		return nil
+	}
+	logger.Debugf("Output file '%s'", fn)
+	return tools.Save(m, fn)
+}
+
+// warnUnreachable warns about atomic operations that are not reached from the
+// entry functions and are hence not verified. The warning is printed
+// independently of the log level since it indicates a gap in the harness.
+func warnUnreachable(m *module.History) {
+	ops, err := m.Unreachable()
+	if err != nil {
+		logger.Debug(err)
+		return
+	}
+	if len(ops) == 0 {
+		return
+	}
+	logger.Printf("Warning: %d atomic operations unreachable from %s are not verified\n",
+		len(ops), strings.Join(rootFlags.entryFunc, ","))
+	for _, op := range ops {
+		logger.Printf("  %s in %s (%v)\n", op.Loc, op.Func, op.Kind)
+	}
+	logger.Println()
+}
+
 type fnGen func(string) string
 
 // newOutputGenerator returns a filename generator function based on the command line arguments.
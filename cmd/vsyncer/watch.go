@@ -0,0 +1,162 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"vsync/checker"
+	"vsync/logger"
+	"vsync/tools"
+)
+
+// fileStamp is the state of a watched file; a missing file has a zero stamp.
+type fileStamp struct {
+	mod  time.Time
+	size int64
+}
+
+// fileStamps maps the watched files to their state.
+type fileStamps map[string]fileStamp
+
+func stampFiles(files []string) fileStamps {
+	stamps := make(fileStamps)
+	for _, fn := range files {
+		var st fileStamp
+		if fi, err := os.Stat(tools.FromSlash(fn)); err == nil {
+			st = fileStamp{mod: fi.ModTime(), size: fi.Size()}
+		}
+		stamps[fn] = st
+	}
+	return stamps
+}
+
+// changed returns the first file whose state changed or an empty string.
+func (s fileStamps) changed() string {
+	var files []string
+	for fn := range s {
+		files = append(files, fn)
+	}
+	sort.Strings(files)
+	now := stampFiles(files)
+	for _, fn := range files {
+		if now[fn] != s[fn] {
+			return fn
+		}
+	}
+	return ""
+}
+
+// watchedFiles returns the input files of the arguments and, for C/C++
+// sources, the headers they include. If the dependencies cannot be determined,
+// eg, because of a syntax error, only the input files are watched.
+func watchedFiles(args []string) []string {
+	var files, sources, flags []string
+	for _, a := range args {
+		switch {
+		case reIsC.MatchString(a) || reIsCPP.MatchString(a):
+			sources = append(sources, a)
+			files = append(files, a)
+		case reIsIR.MatchString(a):
+			files = append(files, a)
+		default:
+			flags = append(flags, a)
+		}
+	}
+	fileCflags, err := parseFileCflags(rootFlags.fileCflags)
+	if err != nil {
+		return files
+	}
+	options := checker.CompileOptions(getCheckerID())()
+	for _, src := range sources {
+		fargs := append(append(append([]string{}, flags...), fileCflags[filepath.Clean(src)]...), src)
+		deps, err := tools.Dependencies(fargs, options)
+		if err != nil {
+			logger.Debugf("could not determine dependencies of %s: %v", src, err)
+			continue
+		}
+		files = append(files, deps...)
+	}
+	return files
+}
+
+// watchRun checks the arguments whenever the watched files change until
+// interrupted. A check in progress is cancelled if the files change.
+func watchRun(args []string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var (
+		h      = harness{name: strings.Join(args, " "), args: args}
+		ticker = time.NewTicker(checkFlags.watchInterval)
+	)
+	defer ticker.Stop()
+
+	for run := 1; ; run++ {
+		stamps := stampFiles(watchedFiles(args))
+		logger.Printf("[%s] #%d checking %s (%d files watched)\n",
+			time.Now().Format("15:04:05"), run, h.name, len(stamps))
+
+		runCtx, cancel := context.WithCancel(ctx)
+		done := make(chan harnessResult, 1)
+		go func() {
+			done <- checkHarness(runCtx, h)
+		}()
+
+		var (
+			finished bool
+			changed  string
+		)
+		for changed == "" {
+			select {
+			case <-ctx.Done():
+				cancel()
+				if !finished {
+					<-done
+				}
+				return nil
+			case r := <-done:
+				finished = true
+				printWatchStatus(run, r)
+			case <-ticker.C:
+				changed = stamps.changed()
+			}
+		}
+		cancel()
+		if !finished {
+			<-done
+			logger.Printf("[%s] #%d cancelled, %s changed\n", time.Now().Format("15:04:05"), run, changed)
+		}
+	}
+}
+
+// printWatchStatus prints a line with the status of a check and the first
+// line of the checker output if it failed.
+func printWatchStatus(run int, r harnessResult) {
+	var (
+		ts     = time.Now().Format("15:04:05")
+		result = "PASS"
+		detail string
+	)
+	switch {
+	case r.passed():
+	case r.failed():
+		result = "FAIL"
+		detail = strings.SplitN(strings.TrimSpace(r.output), "\n", 2)[0]
+	default:
+		result = "ERROR"
+		detail = strings.SplitN(r.err.Error(), "\n", 2)[0]
+	}
+	logger.Printf("[%s] #%d %-5s %v %v %d executions\n", ts, run, result,
+		r.statusString(), r.duration.Round(time.Millisecond), r.numExecutions)
+	if detail != "" {
+		logger.Printf("           %s\n", detail)
+	}
+}
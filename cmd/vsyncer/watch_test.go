@@ -0,0 +1,49 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchStamps(t *testing.T) {
+	var (
+		dir = t.TempDir()
+		fn  = filepath.Join(dir, "lock.ll")
+		hdr = filepath.Join(dir, "lock.h")
+	)
+	assert.Nil(t, os.WriteFile(fn, []byte("; module"), fileMode))
+
+	// IR modules have no dependencies, flags are not watched
+	files := watchedFiles([]string{fn, "-DFOO"})
+	assert.Equal(t, []string{fn}, files)
+
+	stamps := stampFiles(append(files, hdr))
+	assert.Equal(t, "", stamps.changed())
+
+	// a created file is a change as well
+	assert.Nil(t, os.WriteFile(hdr, []byte("#define N 2"), fileMode))
+	assert.Equal(t, hdr, stamps.changed())
+
+	stamps = stampFiles(append(files, hdr))
+	assert.Nil(t, os.WriteFile(fn, []byte("; modified module"), fileMode))
+	assert.Equal(t, fn, stamps.changed())
+}
+
+func TestWatchInterval(t *testing.T) {
+	defer func(watch bool, interval time.Duration) {
+		checkFlags.watch, checkFlags.watchInterval = watch, interval
+	}(checkFlags.watch, checkFlags.watchInterval)
+
+	checkFlags.watch = true
+	for _, interval := range []time.Duration{0, -time.Second} {
+		checkFlags.watchInterval = interval
+		assert.NotNil(t, checkRun(nil, []string{"lock.ll"}))
+	}
+}
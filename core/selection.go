@@ -31,6 +31,8 @@ const (
 	SelectionLoads
 	// SelectionStores selects Stores operations
 	SelectionStores
+	// SelectionInsertions selects candidate points for inserting fences
+	SelectionInsertions
 )
 
 // Group extracts sub selections of coarse selections
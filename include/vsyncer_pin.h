@@ -0,0 +1,18 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+#ifndef VSYNCER_PIN_H
+#define VSYNCER_PIN_H
+
+/* vsyncer keeps the memory ordering of all operations executed between
+ * __VSYNCER_pin_begin() and __VSYNCER_pin_end() when optimizing. */
+static inline void __VSYNCER_pin_begin(void) { }
+static inline void __VSYNCER_pin_end(void) { }
+
+#define vsyncer_pin(...)       \
+    do {                       \
+        __VSYNCER_pin_begin(); \
+        __VA_ARGS__;           \
+        __VSYNCER_pin_end();   \
+    } while (0)
+#endif
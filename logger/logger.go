@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sync"
 )
 
 // Level represents the amount of detail in which the log is output.
@@ -29,6 +30,7 @@ const (
 var (
 	logger *bufio.Writer
 	level  Level
+	mu     sync.Mutex // serializes the writes of concurrent commands
 )
 
 func init() {
@@ -39,6 +41,8 @@ func init() {
 // SetFileDescriptor sets the file descriptor to which the output is sent.
 // If fd is nil, no output is shown.
 func SetFileDescriptor(fd *os.File) {
+	mu.Lock()
+	defer mu.Unlock()
 	logger = bufio.NewWriter(fd)
 }
 
@@ -144,12 +148,16 @@ func Printf(format string, args ...any) {
 var fstr = fmt.Sprintf
 
 func fprint(args ...any) {
+	mu.Lock()
+	defer mu.Unlock()
 	if _, err := fmt.Fprint(logger, args...); err != nil {
 		fail()
 	}
 	flush()
 }
 func fprintln(args ...any) {
+	mu.Lock()
+	defer mu.Unlock()
 	if _, err := fmt.Fprintln(logger, args...); err != nil {
 		fail()
 	}
@@ -34,6 +34,7 @@ type analyzer struct {
 	isAlloca   map[interface{}]bool
 	isParam    map[interface{}]bool
 	count      int
+	pinDepth   int
 	mod        *wrapModule
 }
 
@@ -47,6 +48,14 @@ func newAnalyzer(mod *wrapModule) *analyzer {
 	}
 }
 func (a *analyzer) instCall(inst *ir.InstCall) {
+	// operations between pin markers keep their ordering
+	switch callee := inst.Callee.Ident(); {
+	case strings.Contains(callee, pinBeginMarker):
+		a.pinDepth++
+	case strings.Contains(callee, pinEndMarker) && a.pinDepth > 0:
+		a.pinDepth--
+	}
+
 	// collect local variables that have a name
 	if strings.Contains(inst.Callee.Ident(), "llvm.dbg.declare") ||
 		strings.Contains(inst.Callee.Ident(), "llvm.dbg.addr") {
@@ -79,10 +88,7 @@ func (a *analyzer) instLoad(i ir.Instruction, inst *ir.InstLoad, f *ir.Func, sta
 	}
 
 	in := &wrapInstLoad{InstLoad: inst, wrapInst: newWrap(inst, values, f, stack, a.count)}
-	a.count++
-	a.mod.addInst(a.count, in)
-	return in
-
+	return a.add(in)
 }
 
 func (a *analyzer) instStore(i ir.Instruction, inst *ir.InstStore, f *ir.Func, stack []meta) ir.Instruction {
@@ -111,9 +117,7 @@ func (a *analyzer) instStore(i ir.Instruction, inst *ir.InstStore, f *ir.Func, s
 	}
 
 	in := &wrapInstStore{InstStore: inst, wrapInst: newWrap(inst, values, f, stack, a.count)}
-	a.count++
-	a.mod.addInst(a.count, in)
-	return in
+	return a.add(in)
 }
 
 func (a *analyzer) instFence(inst *ir.InstFence, f *ir.Func, stack []meta) ir.Instruction {
@@ -122,9 +126,7 @@ func (a *analyzer) instFence(inst *ir.InstFence, f *ir.Func, stack []meta) ir.In
 		atomic:   true,
 	}
 	in := &wrapInstFence{InstFence: inst, wrapInst: newWrap(inst, values, f, stack, a.count)}
-	a.count++
-	a.mod.addInst(a.count, in)
-	return in
+	return a.add(in)
 }
 
 func (a *analyzer) instCmpXchg(inst *ir.InstCmpXchg, f *ir.Func, stack []meta) ir.Instruction {
@@ -133,9 +135,7 @@ func (a *analyzer) instCmpXchg(inst *ir.InstCmpXchg, f *ir.Func, stack []meta) i
 		atomic:   true,
 	}
 	in := &wrapInstCmpXchg{InstCmpXchg: inst, wrapInst: newWrap(inst, values, f, stack, a.count)}
-	a.count++
-	a.mod.addInst(a.count, in)
-	return in
+	return a.add(in)
 }
 
 func (a *analyzer) instAtomicRMW(inst *ir.InstAtomicRMW, f *ir.Func, stack []meta) ir.Instruction {
@@ -144,7 +144,14 @@ func (a *analyzer) instAtomicRMW(inst *ir.InstAtomicRMW, f *ir.Func, stack []met
 		atomic:   true,
 	}
 	in := &wrapInstAtomicRMW{InstAtomicRMW: inst, wrapInst: newWrap(inst, values, f, stack, a.count)}
+	return a.add(in)
+}
+
+func (a *analyzer) add(in wrapInstruction) ir.Instruction {
 	a.count++
+	if a.pinDepth > 0 {
+		in.pin()
+	}
 	a.mod.addInst(a.count, in)
 	return in
 }
@@ -192,7 +199,7 @@ func (m *wrapModule) count(atype core.Selection, after bool) int {
 	return len(m.get(atype, after))
 }
 
-func countBarrier(bc *barrierCount, o core.Ordering) {
+func countBarrier(bc *BarrierCount, o core.Ordering) {
 	switch o {
 	case core.Relaxed:
 		bc.Relaxed++
@@ -206,8 +213,8 @@ func countBarrier(bc *barrierCount, o core.Ordering) {
 	}
 }
 
-func (m *wrapModule) barrierCount(sel core.Selection, after bool) barrierCount {
-	bc := new(barrierCount)
+func (m *wrapModule) barrierCount(sel core.Selection, after bool) BarrierCount {
+	bc := new(BarrierCount)
 	for _, i := range m.get(sel, after) {
 		countBarrier(bc, i.getOrdering(after))
 	}
@@ -3,9 +3,36 @@
 
 package module
 
-type barrierCount struct {
+import "vsync/core"
+
+// BarrierCount is the number of atomic operations per memory ordering.
+type BarrierCount struct {
 	SeqCst  int
 	Acquire int
 	Release int
 	Relaxed int
 }
+
+// BarrierCount returns the number of atomic operations per memory ordering with
+// the assignment applied on top of the current mutation. The module itself is
+// not changed.
+func (h *History) BarrierCount(a core.Assignment) (BarrierCount, error) {
+	h.Lock()
+	defer h.Unlock()
+
+	changes, err := h.assign(a.Bs, a.Sel)
+	if err != nil {
+		return BarrierCount{}, err
+	}
+	bc := new(BarrierCount)
+	for id, in := range h.imap {
+		v, has := changes[id]
+		if !has {
+			v = in.values(true)
+		}
+		if v.atomic {
+			countBarrier(bc, v.ordering)
+		}
+	}
+	return *bc, nil
+}
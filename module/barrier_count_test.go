@@ -0,0 +1,33 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package module
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"vsync/core"
+)
+
+func TestBarrierCount(t *testing.T) {
+	h, err := Load(testLock, DefaultConfig())
+	assert.Nil(t, err)
+	defer h.Cleanup()
+
+	for _, b := range viewBitseqs {
+		a := core.Assignment{Bs: core.MustFromBinString(b), Sel: core.SelectionAtomic}
+		bc, err := h.BarrierCount(a)
+		assert.Nil(t, err)
+
+		// same count as after mutating the module
+		assert.Nil(t, h.Mutate(a))
+		assert.Equal(t, h.barrierCount(core.SelectionAtomic, true), bc, b)
+		assert.Nil(t, h.Forget())
+	}
+
+	bc, err := h.BarrierCount(core.Assignment{Bs: core.MustFromBinString("11111111"), Sel: core.SelectionAtomic})
+	assert.Nil(t, err)
+	assert.Equal(t, BarrierCount{SeqCst: 4}, bc)
+}
@@ -0,0 +1,210 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package module
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"vsync/tools"
+)
+
+// plainOrdering is the ordering name of non-atomic operations in baselines.
+const plainOrdering = "plain"
+
+// BaselineEntry is the expected ordering of an operation at a source location.
+type BaselineEntry struct {
+	File     string `json:"file"`
+	Line     int64  `json:"line"`
+	Column   int64  `json:"column,omitempty"`
+	Func     string `json:"function"`
+	Kind     string `json:"kind"`
+	Ordering string `json:"ordering"`
+}
+
+func (e BaselineEntry) String() string {
+	loc := Loc{Filename: e.File, Line: e.Line, Column: e.Column}
+	return fmt.Sprintf("%v %s %s", loc, e.Func, e.Kind)
+}
+
+// key identifies the operations of an entry independently of the ordering.
+func (e BaselineEntry) key() BaselineEntry {
+	e.Ordering = ""
+	return e
+}
+
+// BaselinePath returns the path of the file fn as stored in a baseline in the
+// directory dir: relative to dir if fn is below dir, otherwise absolute. This
+// way, baselines of files in a repository do not depend on where the
+// repository is checked out.
+func BaselinePath(dir, fn string) string {
+	if rel, err := tools.RelPath(dir, fn); err == nil && rel != ".." && !strings.HasPrefix(rel, "../") {
+		return rel
+	}
+	if abs, err := filepath.Abs(tools.FromSlash(fn)); err == nil {
+		return tools.ToSlash(abs)
+	}
+	return tools.ToSlash(fn)
+}
+
+// Baseline returns an entry for each operation of the current mutation sorted
+// by location, see Operations. The files are given as in a baseline in the
+// directory dir, see BaselinePath.
+func (h *History) Baseline(dir string) []BaselineEntry {
+	var entries []BaselineEntry
+	for _, op := range h.Operations() {
+		e := BaselineEntry{
+			File:     BaselinePath(dir, op.Loc.Filename),
+			Line:     op.Loc.Line,
+			Column:   op.Loc.Column,
+			Func:     op.Func,
+			Kind:     kindNames[op.Kind],
+			Ordering: plainOrdering,
+		}
+		if op.Atomic {
+			e.Ordering = orderingNames[op.Ordering]
+		}
+		entries = append(entries, e)
+	}
+	SortBaseline(entries)
+	return entries
+}
+
+// SortBaseline sorts the entries by location, function, kind and ordering.
+func SortBaseline(entries []BaselineEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		switch {
+		case a.File != b.File:
+			return a.File < b.File
+		case a.Line != b.Line:
+			return a.Line < b.Line
+		case a.Column != b.Column:
+			return a.Column < b.Column
+		case a.Func != b.Func:
+			return a.Func < b.Func
+		case a.Kind != b.Kind:
+			return a.Kind < b.Kind
+		default:
+			return a.Ordering < b.Ordering
+		}
+	})
+}
+
+// Drift is a difference between a baseline and the current module. Expected
+// is nil if the operation was added and Actual is nil if it was removed.
+type Drift struct {
+	Expected *BaselineEntry
+	Actual   *BaselineEntry
+}
+
+// orderingStrength ranks the orderings; acquire and release are not
+// comparable with each other.
+var orderingStrength = map[string]int{
+	plainOrdering: 0,
+	"relaxed":     1,
+	"acquire":     2,
+	"release":     2,
+	"seq_cst":     3,
+}
+
+// Kind returns "added", "removed", "stronger", "weaker" or "changed".
+func (d Drift) Kind() string {
+	switch {
+	case d.Expected == nil:
+		return "added"
+	case d.Actual == nil:
+		return "removed"
+	}
+	before := orderingStrength[d.Expected.Ordering]
+	after := orderingStrength[d.Actual.Ordering]
+	switch {
+	case after > before:
+		return "stronger"
+	case after < before:
+		return "weaker"
+	default:
+		return "changed"
+	}
+}
+
+func (d Drift) String() string {
+	switch {
+	case d.Expected == nil:
+		return fmt.Sprintf("%v: %s", d.Actual, d.Actual.Ordering)
+	case d.Actual == nil:
+		return fmt.Sprintf("%v: %s", d.Expected, d.Expected.Ordering)
+	default:
+		return fmt.Sprintf("%v: %s -> %s", d.Actual, d.Expected.Ordering, d.Actual.Ordering)
+	}
+}
+
+// CompareBaseline returns the differences between the expected and the actual
+// entries. Entries with the same location, function and kind are matched by
+// ordering first; the remaining ones are reported as changed, added or
+// removed.
+func CompareBaseline(expected, actual []BaselineEntry) []Drift {
+	var (
+		keys   []BaselineEntry
+		groups = make(map[BaselineEntry]*[2][]BaselineEntry)
+	)
+	add := func(entries []BaselineEntry, side int) {
+		for _, e := range entries {
+			k := e.key()
+			g, ok := groups[k]
+			if !ok {
+				g = new([2][]BaselineEntry)
+				groups[k] = g
+				keys = append(keys, k)
+			}
+			g[side] = append(g[side], e)
+		}
+	}
+	add(expected, 0)
+	add(actual, 1)
+	SortBaseline(keys)
+
+	var drifts []Drift
+	for _, k := range keys {
+		exp, act := unmatched(groups[k][0], groups[k][1])
+		for i := 0; i < len(exp) || i < len(act); i++ {
+			var d Drift
+			if i < len(exp) {
+				d.Expected = &exp[i]
+			}
+			if i < len(act) {
+				d.Actual = &act[i]
+			}
+			drifts = append(drifts, d)
+		}
+	}
+	return drifts
+}
+
+// unmatched removes the entries with the same ordering from both lists.
+func unmatched(exp, act []BaselineEntry) ([]BaselineEntry, []BaselineEntry) {
+	var rest []BaselineEntry
+	used := make([]bool, len(act))
+	for _, e := range exp {
+		found := false
+		for i, a := range act {
+			if !used[i] && a.Ordering == e.Ordering {
+				used[i], found = true, true
+				break
+			}
+		}
+		if !found {
+			rest = append(rest, e)
+		}
+	}
+	var restAct []BaselineEntry
+	for i, a := range act {
+		if !used[i] {
+			restAct = append(restAct, a)
+		}
+	}
+	return rest, restAct
+}
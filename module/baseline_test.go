@@ -0,0 +1,82 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package module
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"vsync/core"
+)
+
+func TestBaseline(t *testing.T) {
+	h, err := Load(testLock, DefaultConfig())
+	assert.Nil(t, err)
+	defer h.Cleanup()
+
+	expected := h.Baseline(".")
+	assert.Len(t, expected, 7)
+	assert.Equal(t, BaselineEntry{File: "testdata/lock.c", Line: 7, Column: 12,
+		Func: "vatomic32_xchg", Kind: "atomicrmw", Ordering: "seq_cst"}, expected[0])
+	assert.Empty(t, CompareBaseline(expected, h.Baseline(".")))
+
+	// release xchg, acquire read, release write and relaxed fence
+	a := core.Assignment{Bs: core.MustFromBinString("00011001"), Sel: core.SelectionAtomic}
+	assert.Nil(t, h.Mutate(a))
+	assert.Nil(t, h.Record())
+
+	var kinds []string
+	for _, d := range CompareBaseline(expected, h.Baseline(".")) {
+		kinds = append(kinds, d.Kind())
+	}
+	assert.Equal(t, []string{"weaker", "weaker", "weaker", "removed"}, kinds)
+}
+
+func TestBaselinePath(t *testing.T) {
+	data, err := os.ReadFile(testLock)
+	assert.Nil(t, err)
+
+	// the same module compiled in two checkouts of a repository
+	var baselines [][]BaselineEntry
+	for _, repo := range []string{"/home/dev/vsync", "/builds/ci/vsync"} {
+		dir := t.TempDir()
+		fn := filepath.Join(dir, "lock.ll")
+		ll := strings.Replace(string(data), `directory: "testdata"`, `directory: "`+repo+`/test"`, 1)
+		assert.Nil(t, os.WriteFile(fn, []byte(ll), 0600))
+
+		h, err := Load(fn, DefaultConfig())
+		assert.Nil(t, err)
+		baselines = append(baselines, h.Baseline(repo))
+		h.Cleanup()
+	}
+	assert.Equal(t, "test/lock.c", baselines[0][0].File)
+	assert.Empty(t, CompareBaseline(baselines[0], baselines[1]))
+
+	// files outside of the directory of the baseline stay absolute
+	assert.Equal(t, "/usr/include/stdatomic.h", BaselinePath("/home/dev/vsync", "/usr/include/stdatomic.h"))
+	assert.Equal(t, "lock.c", BaselinePath("/home/dev/vsync/", "/home/dev/vsync/lock.c"))
+}
+
+func TestCompareBaseline(t *testing.T) {
+	var (
+		e = func(line int64, ordering string) BaselineEntry {
+			return BaselineEntry{File: "lock.c", Line: line, Func: "f", Kind: "load", Ordering: ordering}
+		}
+		expected = []BaselineEntry{e(1, "acquire"), e(1, "relaxed"), e(2, "plain"), e(3, "seq_cst")}
+		actual   = []BaselineEntry{e(1, "relaxed"), e(1, "release"), e(2, "relaxed"), e(4, "seq_cst")}
+	)
+	drifts := CompareBaseline(expected, actual)
+	assert.Len(t, drifts, 4)
+
+	// operations at the same location are matched by ordering first
+	assert.Equal(t, "changed", drifts[0].Kind())
+	assert.Equal(t, "lock.c:1:0 f load: acquire -> release", drifts[0].String())
+	assert.Equal(t, "stronger", drifts[1].Kind())
+	assert.Equal(t, "removed", drifts[2].Kind())
+	assert.Equal(t, "added", drifts[3].Kind())
+}
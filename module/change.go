@@ -0,0 +1,114 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package module
+
+import (
+	"fmt"
+	"strings"
+
+	"vsync/core"
+)
+
+// Change is a difference between the initial module and its current mutation
+// at a source location.
+type Change struct {
+	Loc            Loc
+	Func           string // enclosing function as in the source code
+	Kind           core.AtomicOp
+	AtomicBefore   bool
+	AtomicAfter    bool
+	OrderingBefore core.Ordering
+	OrderingAfter  core.Ordering
+	Delete         bool // the fence is removed
+	Insert         bool // a fence is inserted
+}
+
+// Changes returns the changes of the current mutation compared to the initial
+// module in the order of the operations, as shown by PrintDiff.
+func (h *History) Changes() []Change {
+	var changes []Change
+	h.withState(h.snaps[0], h.state(true), func() {
+		for _, id := range h.imap.sortedKeys() {
+			in := h.imap[id]
+			d := in.diff()
+			if d == nil {
+				continue
+			}
+			changes = append(changes, Change{
+				Loc:            d.Loc,
+				Func:           sourceFuncName(in.wrap().f.Name()),
+				Kind:           mapInstruction(in),
+				AtomicBefore:   d.AtomicBefore,
+				AtomicAfter:    d.AtomicAfter,
+				OrderingBefore: d.OrderingBefore,
+				OrderingAfter:  d.OrderingAfter,
+				Delete:         d.Delete,
+				Insert:         d.Insert,
+			})
+		}
+	})
+	return changes
+}
+
+var (
+	orderingNames = map[core.Ordering]string{
+		core.SeqCst:  "seq_cst",
+		core.Acquire: "acquire",
+		core.Release: "release",
+		core.Relaxed: "relaxed",
+	}
+	kindNames = map[core.AtomicOp]string{
+		core.Fence:   "fence",
+		core.RMW:     "atomicrmw",
+		core.Load:    "load",
+		core.Store:   "store",
+		core.Cmpxchg: "cmpxchg",
+	}
+)
+
+// Description returns a plain text description of the change.
+func (c Change) Description() string {
+	var (
+		kind = kindNames[c.Kind]
+		to   = orderingNames[c.OrderingAfter]
+	)
+	switch {
+	case c.Delete:
+		return "remove fence"
+	case c.Insert:
+		return fmt.Sprintf("insert %s fence", to)
+	case c.AtomicBefore && c.AtomicAfter:
+		if fn, ok := c.Replacement(); ok {
+			return fmt.Sprintf("replace %s with %s", c.Func, fn)
+		}
+		return fmt.Sprintf("change %s from %s to %s", kind, orderingNames[c.OrderingBefore], to)
+	case c.AtomicAfter:
+		return fmt.Sprintf("change %s to atomic", kind)
+	default:
+		return fmt.Sprintf("change %s to non-atomic", kind)
+	}
+}
+
+// Replacement returns the vatomic function implementing the new ordering if
+// the operation is in a vatomic function, see changeOrdering.
+func (c Change) Replacement() (string, bool) {
+	if !strings.Contains(c.Func, "vatomic") || c.Delete || c.Insert ||
+		!c.AtomicBefore || !c.AtomicAfter {
+		return "", false
+	}
+	fn := c.Func
+	for _, suffix := range []string{"_rel", "_rlx", "_acq"} {
+		fn = strings.TrimSuffix(fn, suffix)
+	}
+	switch c.OrderingAfter {
+	case core.Relaxed:
+		fn += "_rlx"
+	case core.Acquire:
+		fn += "_acq"
+	case core.Release:
+		fn += "_rel"
+	default:
+	}
+	return fn, true
+}
@@ -0,0 +1,45 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package module
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"vsync/core"
+)
+
+func TestChanges(t *testing.T) {
+	h, err := Load(testLock, DefaultConfig())
+	assert.Nil(t, err)
+	defer h.Cleanup()
+	assert.Empty(t, h.Changes())
+
+	// release xchg and write, relaxed fence; the read is reset to seq_cst
+	// when lifting the loads
+	a := core.Assignment{Bs: core.MustFromBinString("00011001"), Sel: core.SelectionAtomic}
+	assert.Nil(t, h.Mutate(a))
+	assert.Nil(t, h.Record())
+	a = core.Assignment{Bs: core.MustFromBinString("011"), Sel: core.SelectionLoads}
+	assert.Nil(t, h.Mutate(a))
+
+	var descs []string
+	for _, c := range h.Changes() {
+		descs = append(descs, c.Description())
+	}
+	assert.Equal(t, []string{
+		"replace vatomic32_xchg with vatomic32_xchg_rel",
+		"change load to atomic",
+		"replace vatomic32_write with vatomic32_write_rel",
+		"remove fence",
+	}, descs)
+
+	c := h.Changes()[0]
+	assert.Equal(t, Loc{Filename: "testdata/lock.c", Directory: "testdata", Line: 7, Column: 12}, c.Loc)
+	assert.Equal(t, core.RMW, c.Kind)
+	fn, ok := c.Replacement()
+	assert.True(t, ok)
+	assert.Equal(t, "vatomic32_xchg_rel", fn)
+}
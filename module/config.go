@@ -5,13 +5,16 @@ package module
 
 // Config enables multiple options when loading a LLVM IR module.
 type Config struct {
-	Atomics      bool     // whether we are selecting atomics/non-atomics
-	EntryFunc    []string // a list of entry functions for analysis, default "run"
-	Expand       bool     // whether it should expand the callgraph
-	ExpandOnly   []string // a list of function prefixes to expand
-	IdentifyOnly []string // a list of function prefixes to identify/atomify
-	SkipFuncPref []string // a list of function prefixes to skip identify/atomify
-	Args         []string // a list of arguments to pass to the command line of the checker
+	Atomics      bool     `json:"atomics,omitempty"`        // whether we are selecting atomics/non-atomics
+	EntryFunc    []string `json:"entry_func,omitempty"`     // a list of entry functions for analysis, default "run"
+	Expand       bool     `json:"expand,omitempty"`         // whether it should expand the callgraph
+	ExpandOnly   []string `json:"expand_only,omitempty"`    // a list of function prefixes to expand
+	IdentifyOnly []string `json:"identify_only,omitempty"`  // a list of function prefixes to identify/atomify
+	SkipFuncPref []string `json:"skip_func_pref,omitempty"` // a list of function prefixes to skip identify/atomify
+	PinFunc      []string `json:"pin_func,omitempty"`       // a list of functions whose operations keep their ordering
+	PinLoc       []string `json:"pin_loc,omitempty"`        // a list of source locations (file:line) whose operations keep their ordering
+	InsertFences bool     `json:"insert_fences,omitempty"`  // whether candidate fences are added after plain stores and before plain loads
+	Args         []string `json:"args,omitempty"`           // a list of arguments to pass to the command line of the checker
 }
 
 // DefaultConfig returns a default configuration for loading a module
@@ -7,6 +7,7 @@ import (
 	"bufio"
 	"fmt"
 	"os"
+	"path"
 	"regexp"
 	"strings"
 
@@ -49,12 +50,24 @@ type diffEntry struct {
 	OrderingBefore core.Ordering
 	OrderingAfter  core.Ordering
 	Delete         bool
+	Insert         bool
 	FuncName       string
 	CloneName      string
 	Name           string
 }
 
-var reClone = regexp.MustCompile(`(.*)__vsyncer_expand_[0-9]+$`)
+var (
+	reClone  = regexp.MustCompile(`(.*)__vsyncer_expand_[0-9]+$`)
+	reLinked = regexp.MustCompile(`(.*)\.[0-9]+$`)
+)
+
+// sourceFuncName returns the function name as in the source code, ie,
+// without the suffixes added by the expansion or by llvm-link when renaming
+// internal functions with the same name.
+func sourceFuncName(name string) string {
+	name = reClone.ReplaceAllString(name, "${1}")
+	return reLinked.ReplaceAllString(name, "${1}")
+}
 
 func (inst *wrapInst) diff() *diffEntry {
 
@@ -82,7 +95,7 @@ func (inst *wrapInst) diff() *diffEntry {
 			entry.Delete = true
 		}
 
-		entry.FuncName = reClone.ReplaceAllString(inst.f.GlobalName, "${1}")
+		entry.FuncName = sourceFuncName(inst.f.GlobalName)
 		if entry.FuncName != inst.f.GlobalName {
 			entry.CloneName = inst.f.GlobalName
 		}
@@ -110,6 +123,10 @@ type Loc struct {
 	Column    int64
 }
 
+func (loc Loc) String() string {
+	return fmt.Sprintf("%s:%d:%d", loc.Filename, loc.Line, loc.Column)
+}
+
 func (loc *Loc) update(line, col int64, filename string, directory string) bool {
 	// update location
 	if loc.Line == 0 {
@@ -154,18 +171,18 @@ func readLoc(md meta) Loc {
 			col = n.Column
 			node = n.Scope
 		case *metadata.DILexicalBlock:
-			filename = n.File.Directory + "/" + n.File.Filename
+			filename = joinPath(n.File.Directory, n.File.Filename)
 			directory = n.File.Directory
 			line = n.Line
 			col = n.Column
 			node = n.Scope
 		case *metadata.DISubprogram:
-			filename = n.File.Directory + "/" + n.File.Filename
+			filename = joinPath(n.File.Directory, n.File.Filename)
 			directory = n.File.Directory
 			line = n.Line
 			node = n.Scope
 		case *metadata.DIFile:
-			filename = n.Directory + "/" + n.Filename
+			filename = joinPath(n.Directory, n.Filename)
 			directory = n.Directory
 			node = nil
 		default:
@@ -175,6 +192,16 @@ func readLoc(md meta) Loc {
 	return loc
 }
 
+// joinPath joins the directory and filename of debug metadata. Modules
+// compiled in other directories and linked together may have absolute
+// filenames.
+func joinPath(dir, fn string) string {
+	if dir == "" || path.IsAbs(fn) {
+		return fn
+	}
+	return dir + "/" + fn
+}
+
 func printDiffEntry(i int, d *diffEntry) error {
 	var (
 		line string
@@ -283,6 +310,9 @@ func changeOrdering(d *diffEntry) (string, error) {
 	if d.Delete {
 		return naColor("remove it"), nil
 	}
+	if d.Insert {
+		return fmt.Sprintf("insert fence here (%s)", withColor(d.OrderingAfter)), nil
+	}
 
 	if !strings.Contains(d.FuncName, "vatomic") {
 		return fmt.Sprintf("change %s to %s", d.Name, withColor(d.OrderingAfter)), nil
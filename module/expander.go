@@ -15,7 +15,9 @@ import (
 	"vsync/logger"
 )
 
-func expandVisitor(mod *ir.Module) VisitCallback {
+// expandVisitor clones the called vatomic functions, the number of clones is
+// added to clones.
+func expandVisitor(mod *ir.Module, clones *int) VisitCallback {
 	// count number of clones of a function
 	count := make(map[string]int)
 
@@ -52,6 +54,7 @@ func expandVisitor(mod *ir.Module) VisitCallback {
 			// clone function
 			cloneFname := fmt.Sprintf("%s__vsyncer_expand_%d", fname, count[fname]-1)
 			in.Callee = cloneFunc(mod, fname, cloneFname)
+			*clones++
 
 			// return call instruction to replace current one
 			if verboseVisitor {
@@ -0,0 +1,181 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package module
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/llir/llvm/ir"
+
+	"vsync/core"
+)
+
+// graphColors follows the colors of the diff output.
+var graphColors = map[core.Ordering]string{
+	core.SeqCst:  "cyan4",
+	core.Acquire: "goldenrod",
+	core.Release: "green4",
+	core.Relaxed: "red",
+}
+
+type graphEdge struct {
+	from, to *ir.Func
+	thread   bool
+}
+
+// WriteGraph writes the call graph reached from the entry functions in DOT
+// format. Thread creations are dashed edges, skipped functions are gray and
+// each function is labeled with the number of atomic operations per ordering
+// in the current mutation. If colored is set, the counts use the colors of
+// the diff output.
+func (h *History) WriteGraph(w io.Writer, colored bool) error {
+	h.Lock()
+	defer h.Unlock()
+
+	var (
+		nodes   []*ir.Func
+		reached = make(map[*ir.Func]bool)
+		skipped = make(map[*ir.Func]bool)
+		edges   = make(map[graphEdge]bool)
+		entries = make(map[*ir.Func]bool)
+	)
+	add := func(f *ir.Func) {
+		if !reached[f] {
+			reached[f] = true
+			nodes = append(nodes, f)
+		}
+	}
+	for _, name := range h.cfg.EntryFunc {
+		for _, f := range h.Funcs {
+			if f.Name() == name {
+				entries[f] = true
+				add(f)
+			}
+		}
+	}
+	for i := 0; i < len(nodes); i++ {
+		f := nodes[i]
+		if skipped[f] {
+			continue
+		}
+		for _, block := range f.Blocks {
+			for _, inst := range block.Insts {
+				call, ok := inst.(*ir.InstCall)
+				if !ok {
+					continue
+				}
+				callee := calledFunc(call)
+				if callee == nil || strings.HasPrefix(callee.Name(), "llvm.") {
+					continue
+				}
+				thread := isThreadCreate(call)
+				if !thread && isSkipped(callee.Name(), h.cfg.SkipFuncPref) {
+					skipped[callee] = true
+				}
+				edges[graphEdge{from: f, to: callee, thread: thread}] = true
+				add(callee)
+			}
+		}
+	}
+
+	// count the atomic operations of each function by ordering
+	counts := make(map[*ir.Func]*BarrierCount)
+	for _, in := range h.imap {
+		if !inModule(in) || !in.isAtomic(true) {
+			continue
+		}
+		f := in.wrap().f
+		if counts[f] == nil {
+			counts[f] = new(BarrierCount)
+		}
+		countBarrier(counts[f], in.getOrdering(true))
+	}
+
+	var sb strings.Builder
+	sb.WriteString("digraph callgraph {\n")
+	sb.WriteString("\tnode [shape=box, fontname=\"monospace\"];\n")
+	for _, f := range nodes {
+		attrs := []string{"label=" + graphLabel(f, counts[f], colored)}
+		switch {
+		case entries[f]:
+			attrs = append(attrs, "style=bold")
+		case skipped[f]:
+			attrs = append(attrs, "style=dashed", "color=gray", "fontcolor=gray")
+		case reClone.MatchString(f.Name()):
+			attrs = append(attrs, "style=rounded")
+		}
+		fmt.Fprintf(&sb, "\t%q [%s];\n", f.Name(), strings.Join(attrs, ", "))
+	}
+
+	var sorted []graphEdge
+	for e := range edges {
+		sorted = append(sorted, e)
+	}
+	index := make(map[*ir.Func]int)
+	for i, f := range nodes {
+		index[f] = i
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if index[a.from] != index[b.from] {
+			return index[a.from] < index[b.from]
+		}
+		if index[a.to] != index[b.to] {
+			return index[a.to] < index[b.to]
+		}
+		return !a.thread && b.thread
+	})
+	for _, e := range sorted {
+		attr := ""
+		if e.thread {
+			attr = " [style=dashed, label=\"thread\"]"
+		}
+		fmt.Fprintf(&sb, "\t%q -> %q%s;\n", e.from.Name(), e.to.Name(), attr)
+	}
+	sb.WriteString("}\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// graphLabel returns the DOT label of a function with its ordering counts.
+func graphLabel(f *ir.Func, bc *BarrierCount, colored bool) string {
+	name := f.Name()
+	if m := reClone.FindStringSubmatch(name); m != nil {
+		name = fmt.Sprintf("%s\n(clone %s)", m[1], strings.TrimPrefix(name, m[1]+"__vsyncer_expand_"))
+	}
+	if bc == nil {
+		return fmt.Sprintf("%q", name)
+	}
+	counts := []struct {
+		o core.Ordering
+		n int
+		s string
+	}{
+		{core.SeqCst, bc.SeqCst, "sc"},
+		{core.Acquire, bc.Acquire, "acq"},
+		{core.Release, bc.Release, "rel"},
+		{core.Relaxed, bc.Relaxed, "rlx"},
+	}
+	if !colored {
+		var parts []string
+		for _, c := range counts {
+			parts = append(parts, fmt.Sprintf("%s %d", c.s, c.n))
+		}
+		return fmt.Sprintf("%q", name+"\n"+strings.Join(parts, " "))
+	}
+	var parts []string
+	for _, c := range counts {
+		text := fmt.Sprintf("%s %d", c.s, c.n)
+		if c.n > 0 {
+			text = fmt.Sprintf("<font color=%q>%s</font>", graphColors[c.o], text)
+		}
+		parts = append(parts, text)
+	}
+	name = strings.ReplaceAll(name, "\n", "<br/>")
+	return fmt.Sprintf("<%s<br/>%s>", name, strings.Join(parts, " "))
+}
@@ -0,0 +1,41 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package module
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteGraph(t *testing.T) {
+	h, err := Load(testLock, DefaultConfig())
+	assert.Nil(t, err)
+	defer h.Cleanup()
+
+	var sb strings.Builder
+	assert.Nil(t, h.WriteGraph(&sb, false))
+	g := sb.String()
+
+	for _, line := range []string{
+		`"main" [label="main", style=bold];`,
+		`"main" -> "run" [style=dashed, label="thread"];`,
+		`"main" -> "run";`,
+		`"main" -> "pthread_join";`,
+		`"pthread_join" [label="pthread_join", style=dashed, color=gray, fontcolor=gray];`,
+		`"run" -> "vatomic_fence__vsyncer_expand_0";`,
+		`"vatomic_fence__vsyncer_expand_0" [label="vatomic_fence\n(clone 0)\nsc 1 acq 0 rel 0 rlx 0", style=rounded];`,
+		`"acquire" -> "vatomic32_xchg__vsyncer_expand_0";`,
+	} {
+		assert.Contains(t, g, "\t"+line+"\n")
+	}
+	// the original of expanded functions is not reached
+	assert.NotContains(t, g, `"vatomic_fence" [`)
+	assert.NotContains(t, g, "pthread_create")
+
+	sb.Reset()
+	assert.Nil(t, h.WriteGraph(&sb, true))
+	assert.Contains(t, sb.String(), `label=<vatomic_fence<br/>(clone 0)<br/><font color="cyan4">sc 1</font> acq 0 rel 0 rlx 0>`)
+}
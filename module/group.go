@@ -0,0 +1,41 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package module
+
+import (
+	"strings"
+
+	"vsync/core"
+)
+
+// Groups returns, for each operation of the selection in bitseq order, the
+// groups containing the operation from the outermost to the innermost: its
+// source file, the function calling the atomic and the clone of the vatomic
+// function the operation is in. Operations outside vatomic functions have the
+// function as innermost group.
+func (m *wrapModule) Groups(sel core.Selection) [][]string {
+	wi := m.get(sel, true)
+	var groups [][]string
+	for _, k := range wi.sortedKeys() {
+		w := wi.get(k).wrap()
+		var (
+			file  = "file " + getLoc(w.stack).Filename
+			fn    = sourceFuncName(w.f.Name())
+			clone = "func " + fn
+		)
+		// the innermost function of the call chain that is not atomic
+		chain := funcChain(w.stack)
+		for i := len(chain) - 1; i >= 0; i-- {
+			if !strings.Contains(chain[i], "vatomic") {
+				fn = chain[i]
+				break
+			}
+		}
+		if reClone.MatchString(w.f.Name()) {
+			clone = "clone " + w.f.Name()
+		}
+		groups = append(groups, []string{file, "func " + fn, clone})
+	}
+	return groups
+}
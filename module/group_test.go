@@ -0,0 +1,27 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package module
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"vsync/core"
+)
+
+func TestGroups(t *testing.T) {
+	h, err := Load(testLock, DefaultConfig())
+	assert.Nil(t, err)
+	defer h.Cleanup()
+
+	// the atomics are called by the functions of the lock
+	assert.Equal(t, [][]string{
+		{"file testdata/lock.c", "func acquire", "clone vatomic32_xchg__vsyncer_expand_0"},
+		{"file testdata/lock.c", "func acquire", "clone vatomic32_read__vsyncer_expand_0"},
+		{"file testdata/lock.c", "func release", "clone vatomic32_write__vsyncer_expand_0"},
+		{"file testdata/lock.c", "func run", "clone vatomic_fence__vsyncer_expand_0"},
+	}, h.Groups(core.SelectionAtomic))
+	assert.Len(t, h.Groups(core.SelectionAtomic), h.Assignment(core.SelectionAtomic).Bs.Length()/2)
+}
@@ -11,51 +11,64 @@ import (
 
 	"vsync/core"
 	"vsync/logger"
-	"vsync/tools"
 )
 
 // History represents the mutation history of an LLVM IR module.
+//
+// The module is parsed once. Recording a mutation takes a snapshot of the
+// orderings and atomic flags of the instructions; files are only written on
+// request, eg, with tools.Dump.
 type History struct {
 	*wrapModule
-	cfg Config
+	cfg     Config
+	loadCfg Config // configuration given to Load
 
-	base  string
-	count int
+	base string
+	name string // name of the analyzed module
 
-	hist []string
-	mods []*wrapModule
+	snaps []snapshot // recorded states, the first is the initial state
 
 	mutations struct {
 		current  []core.Assignment
 		recorded []core.Assignment
+		steps    [][]core.Assignment // recorded mutations per Record call
 	}
 }
 
 // Load parses and analyzes an LLVM IR module.
 func Load(fn string, cfg Config) (*History, error) {
-	var efn = fn
+	var (
+		name    = fn
+		loadCfg = cfg
+	)
 
-	if cfg.Expand {
-		logger.Infof("Parse '%s'", fn)
-		mod, err := asm.ParseFile(fn)
-		if err != nil {
-			return nil, err
-		}
+	logger.Infof("Parse '%s'", fn)
+	mod, err := asm.ParseFile(fn)
+	if err != nil {
+		return nil, err
+	}
 
+	if cfg.Expand {
 		logger.Infof("Expand '%s'", fn)
-		if err := visitModule(mod, cfg.EntryFunc, expandVisitor(mod), cfg); err != nil {
+		var clones int
+		if err := visitModule(mod, cfg.EntryFunc, expandVisitor(mod, &clones), cfg); err != nil {
 			return nil, err
 		}
 
-		efn = genName(fn, ".expand")
-		if err := tools.Dump(mod, efn); err != nil {
-			return nil, err
+		// Cloned functions share their blocks with the original functions,
+		// the module is reparsed to separate them.
+		if clones > 0 {
+			name = genName(fn, ".expand")
+			logger.Infof("Parse '%s'", name)
+			if mod, err = asm.ParseString(name, mod.String()); err != nil {
+				return nil, err
+			}
 		}
 		cfg.Expand = false
 	}
 
-	// load wrapped module
-	wmod, err := loadModule(efn, cfg)
+	// analyze wrapped module
+	wmod, err := analyzeModule(name, mod, cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -63,51 +76,35 @@ func Load(fn string, cfg Config) (*History, error) {
 	return &History{
 		wrapModule: wmod,
 		cfg:        cfg,
-		count:      0,
+		loadCfg:    loadCfg,
 		base:       fn,
-		hist:       []string{efn},
-		mods:       []*wrapModule{wmod},
+		name:       name,
+		snaps:      []snapshot{wmod.state(false)},
 	}, nil
 }
 
 // Record saves the current mutation extending the history of the module.
 func (h *History) Record() error {
-	fn := genName(h.base, h.new())
-	if err := tools.Dump(h.Module, fn); err != nil {
-		return err
-	}
-	wm, err := loadModule(fn, h.cfg)
-	if err != nil {
-		return err
-	}
-	h.add(fn, wm)
-	h.wrapModule = wm
-
+	h.Lock()
+	defer h.Unlock()
+	h.commit()
+	h.snaps = append(h.snaps, h.state(false))
 	h.recordMut()
 	return nil
 }
 
 // Forget drops non-recorded mutations
 func (h *History) Forget() error {
-	fn := h.last()
-	wm, err := loadModule(fn, h.cfg)
-	if err != nil {
-		return err
-	}
-	h.mods[len(h.mods)-1] = wm
-	h.wrapModule = wm
+	h.Lock()
+	defer h.Unlock()
+	h.rollback()
 	h.clearMut()
 	return nil
 }
 
-// Cleanup removes temporary files.
+// Cleanup releases the resources of the history. The history does not
+// create temporary files, Cleanup is kept for compatibility.
 func (h *History) Cleanup() {
-	for _, fn := range h.hist {
-		logger.Debugf("Removing history file '%s'", fn)
-		if err := tools.Remove(fn); err != nil {
-			logger.Debug(err)
-		}
-	}
 }
 
 func (h *History) recordMut() {
@@ -115,6 +112,7 @@ func (h *History) recordMut() {
 	if len(muts) > 0 {
 		h.mutations.recorded = append(h.mutations.recorded, muts...)
 	}
+	h.mutations.steps = append(h.mutations.steps, muts)
 	h.clearMut()
 }
 
@@ -126,33 +124,26 @@ func (h *History) appendMutation(mut core.Assignment) {
 	h.mutations.current = append(h.mutations.current, mut)
 }
 
-func (h *History) add(name string, mod *wrapModule) {
-	h.hist = append(h.hist, name)
-	h.mods = append(h.mods, mod)
-}
-
-func (h *History) last() string {
-	return h.hist[len(h.hist)-1]
+// at calls fn with the module in the state of the i-th snapshot. The state
+// after the mutation is the next snapshot or the current mutation.
+func (h *History) at(i int, fn func()) {
+	after := h.state(true)
+	if i+1 < len(h.snaps) {
+		after = h.snaps[i+1]
+	}
+	h.withState(h.snaps[i], after, fn)
 }
 
-func (h *History) strFiles(i int) string {
-	if i >= len(h.hist) {
-		return ""
-	}
-	sep := " --> "
-	if i == 0 {
-		sep = ""
+func (h *History) strFiles() string {
+	s := h.name
+	for i := 1; i < len(h.snaps); i++ {
+		s += fmt.Sprintf(" --> #%d", i)
 	}
-	return fmt.Sprintf("%s%s%s", sep, h.hist[i], h.strFiles(i+1))
+	return s
 }
 
 func (h *History) length() int {
-	return len(h.hist)
-}
-
-func (h *History) new() string {
-	h.count++
-	return fmt.Sprintf("_%d", h.count)
+	return len(h.snaps)
 }
 
 var reLL = regexp.MustCompile(`(.*)\.ll$`)
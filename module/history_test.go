@@ -0,0 +1,81 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package module
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"vsync/core"
+)
+
+const testLock = "testdata/lock.ll"
+
+func TestHistoryRecord(t *testing.T) {
+	h, err := Load(testLock, DefaultConfig())
+	assert.Nil(t, err)
+	defer h.Cleanup()
+
+	initial := h.String()
+	assert.Equal(t, "11111111", h.Assignment(core.SelectionAtomic).Bs.ToBinString())
+
+	// relax everything but the xchg (release), which leaves the fence out
+	a := core.Assignment{Bs: core.MustFromBinString("00000001"), Sel: core.SelectionAtomic}
+	assert.Nil(t, h.Mutate(a))
+	assert.Nil(t, h.Record())
+	assert.Equal(t, "000001", h.Assignment(core.SelectionAtomic).Bs.ToBinString())
+	assert.Equal(t, 0, h.count(core.SelectionFences, true))
+	recorded := h.String()
+	assert.NotEqual(t, initial, recorded)
+	// the original vatomic_fence function is kept, only its clone is changed
+	assert.Equal(t, strings.Count(initial, "fence seq_cst")-1, strings.Count(recorded, "fence seq_cst"))
+
+	// mutations after the record are dropped by Forget
+	a = core.Assignment{Bs: core.MustFromBinString("000000"), Sel: core.SelectionAtomic}
+	assert.Nil(t, h.Mutate(a))
+	assert.NotEqual(t, recorded, h.String())
+	assert.Nil(t, h.Forget())
+	assert.Equal(t, recorded, h.String())
+
+	// undo brings back the initial module
+	assert.Nil(t, h.Undo())
+	assert.Equal(t, initial, h.String())
+	assert.Equal(t, "11111111", h.Assignment(core.SelectionAtomic).Bs.ToBinString())
+}
+
+func BenchmarkLoad(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		h, err := Load(testLock, DefaultConfig())
+		if err != nil {
+			b.Fatal(err)
+		}
+		h.Cleanup()
+	}
+}
+
+// BenchmarkRecord measures the operations on the history done by optimize
+// besides the checker calls.
+func BenchmarkRecord(b *testing.B) {
+	h, err := Load(testLock, DefaultConfig())
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer h.Cleanup()
+	a := h.Assignment(core.SelectionAtomic)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := h.Record(); err != nil {
+			b.Fatal(err)
+		}
+		if err := h.Mutate(a); err != nil {
+			b.Fatal(err)
+		}
+		_ = h.String()
+		if err := h.Forget(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package module
+
+import (
+	"sort"
+
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/enum"
+
+	"vsync/core"
+)
+
+// wrapInstInsertion is a candidate point for inserting a fence. The fence is
+// part of the module only if its ordering is not relaxed. Unlike recorded
+// relaxed fences, candidates are never removed from the selection.
+type wrapInstInsertion struct {
+	wrapInstFence
+}
+
+func (w *wrapInstInsertion) removed() bool { return false }
+
+func (w *wrapInstInsertion) diff() *diffEntry {
+	entry := w.wrapInstFence.diff()
+	if entry != nil && !entry.Delete {
+		entry.Insert = true
+	}
+	return entry
+}
+
+// inModule returns true if the instruction is part of the current mutation,
+// ie, it is neither a removed fence nor a candidate that is not inserted.
+func inModule(in wrapInstruction) bool {
+	if _, ok := in.(*wrapInstInsertion); ok {
+		return in.getOrdering(true) != core.Relaxed
+	}
+	return !in.removed()
+}
+
+// insertionPoint is the position of a candidate fence in a block. The fence
+// is inserted before the instruction at index idx.
+type insertionPoint struct {
+	block *ir.Block
+	idx   int
+	near  wrapInstruction // operation whose location the candidate takes
+	fence *wrapInstInsertion
+}
+
+// addInsertions adds candidate fences after the plain stores and before the
+// plain loads of the tracked operations. Candidates get ids after the ids of
+// the existing operations, so that the bitseqs of other selections are not
+// affected, and are initially relaxed, ie, not inserted.
+func (m *wrapModule) addInsertions() {
+	pos := make(map[ir.Instruction]insertionPoint)
+	for _, f := range m.Funcs {
+		for _, block := range f.Blocks {
+			for i, inst := range block.Insts {
+				pos[inst] = insertionPoint{block: block, idx: i}
+			}
+		}
+	}
+
+	var (
+		points []insertionPoint
+		seen   = make(map[*ir.Block]map[int]bool)
+	)
+	for _, id := range m.imap.sortedKeys() {
+		var (
+			in = m.imap[id]
+			p  insertionPoint
+			ok bool
+		)
+		switch in.(type) {
+		case *wrapInstStore:
+			p, ok = pos[in]
+			p.idx++
+		case *wrapInstLoad:
+			p, ok = pos[in]
+		default:
+		}
+		// a store followed by a load share the insertion point
+		if !ok || in.isAtomic(false) || seen[p.block][p.idx] {
+			continue
+		}
+		if seen[p.block] == nil {
+			seen[p.block] = make(map[int]bool)
+		}
+		seen[p.block][p.idx] = true
+		p.near = in
+		points = append(points, p)
+	}
+
+	// the ids follow the order of the operations, the candidates are
+	// inserted from the last point of each block so that the indices of
+	// the remaining points stay valid
+	next := len(m.imap)
+	for i := range points {
+		id := next + i + 1
+		points[i].fence = newInsertion(points[i].near, id)
+		m.addInst(id, points[i].fence)
+	}
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].idx > points[j].idx
+	})
+	for _, p := range points {
+		insts := p.block.Insts
+		insts = append(insts[:p.idx], append([]ir.Instruction{p.fence}, insts[p.idx:]...)...)
+		p.block.Insts = insts
+	}
+}
+
+// newInsertion returns a relaxed candidate fence with the location of the
+// operation near it.
+func newInsertion(near wrapInstruction, id int) *wrapInstInsertion {
+	var (
+		w     = near.wrap()
+		fence = &ir.InstFence{Ordering: enum.AtomicOrderingMonotonic}
+		stack = make([]meta, len(w.stack))
+		v     = wrapValues{ordering: core.Relaxed, atomic: true}
+	)
+	fence.Metadata = w.inst.(meta).MDAttachments()
+	copy(stack, w.stack)
+	stack[len(stack)-1] = fence
+
+	in := new(wrapInstInsertion)
+	in.InstFence = fence
+	in.wrapInst = newWrap(fence, v, w.f, stack, id)
+	return in
+}
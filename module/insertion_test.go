@@ -0,0 +1,70 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package module
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"vsync/core"
+)
+
+func TestInsertions(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.InsertFences = true
+	h, err := Load(testLock, cfg)
+	assert.Nil(t, err)
+	defer h.Cleanup()
+
+	// candidates before the loads of cnt and after its store, they do not
+	// change the other selections
+	initial := h.String()
+	assert.Equal(t, "000000", h.Assignment(core.SelectionInsertions).Bs.ToBinString())
+	assert.Equal(t, "11111111", h.Assignment(core.SelectionAtomic).Bs.ToBinString())
+	assert.Equal(t, 2, strings.Count(initial, "fence seq_cst"))
+	assert.NotContains(t, initial, "fence monotonic")
+
+	// insert a fence before the load of cnt in run
+	a := core.Assignment{Bs: core.MustFromBinString("000011"), Sel: core.SelectionInsertions}
+	v, err := h.View(a)
+	assert.Nil(t, err)
+	assert.Nil(t, h.Mutate(a))
+	assert.Equal(t, h.String(), v.String())
+	assert.Contains(t, h.String(), "fence seq_cst, !dbg !32\n\t%2 = load i32, i32* @cnt")
+
+	diff := h.Diff()
+	assert.Len(t, diff, 1)
+	assert.True(t, diff[0].Insert)
+	assert.Equal(t, core.SeqCst, diff[0].OrderingAfter)
+	assert.Equal(t, int64(29), diff[0].Loc.Line)
+
+	// recorded candidates stay in the selection when relaxed again
+	assert.Nil(t, h.Record())
+	assert.Nil(t, h.Mutate(core.Assignment{Bs: core.MustFromBinString("000000"), Sel: core.SelectionInsertions}))
+	assert.Nil(t, h.Record())
+	assert.Equal(t, "000000", h.Assignment(core.SelectionInsertions).Bs.ToBinString())
+	assert.Equal(t, initial, h.String())
+}
+
+func TestInsertionOperations(t *testing.T) {
+	plain, err := Load(testLock, DefaultConfig())
+	assert.Nil(t, err)
+	defer plain.Cleanup()
+
+	cfg := DefaultConfig()
+	cfg.InsertFences = true
+	h, err := Load(testLock, cfg)
+	assert.Nil(t, err)
+	defer h.Cleanup()
+
+	// candidates that are not inserted are not operations of the module
+	n := len(plain.Operations())
+	assert.Len(t, h.Operations(), n)
+
+	a := core.Assignment{Bs: core.MustFromBinString("000011"), Sel: core.SelectionInsertions}
+	assert.Nil(t, h.Mutate(a))
+	assert.Len(t, h.Operations(), n+1)
+}
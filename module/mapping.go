@@ -4,13 +4,15 @@
 package module
 
 import (
+	"fmt"
+
 	"vsync/core"
 	"vsync/logger"
 )
 
 func mapInstruction(in wrapInstruction) core.AtomicOp {
 	switch in.(type) {
-	case *wrapInstFence:
+	case *wrapInstFence, *wrapInstInsertion:
 		return core.Fence
 	case *wrapInstLoad:
 		return core.Load
@@ -29,3 +31,31 @@ func mapInstruction(in wrapInstruction) core.AtomicOp {
 func mapOrdering(in wrapInstruction, val int) core.Ordering {
 	return mapInstruction(in).GetOrdering(val)
 }
+
+// Kinds returns the kind of each operation of the selection in bitseq order.
+func (m *wrapModule) Kinds(sel core.Selection) []core.AtomicOp {
+	wi := m.get(sel, true)
+	var kinds []core.AtomicOp
+	for _, k := range wi.sortedKeys() {
+		kinds = append(kinds, mapInstruction(wi.get(k)))
+	}
+	return kinds
+}
+
+// Locations returns the source location of each operation of the selection in
+// bitseq order. Operations in atomic functions have the location of the call
+// to the atomic function, ie, the location in the library or program using it.
+// It fails if an operation has no location, eg, the module was compiled
+// without debug information, since unrelated operations would share it.
+func (m *wrapModule) Locations(sel core.Selection) ([]string, error) {
+	wi := m.get(sel, true)
+	var locs []string
+	for _, k := range wi.sortedKeys() {
+		loc := getLoc(wi.get(k).wrap().stack)
+		if loc.Filename == "" {
+			return nil, fmt.Errorf("operation %d has no source location, compile with -g", k)
+		}
+		locs = append(locs, loc.String())
+	}
+	return locs, nil
+}
@@ -0,0 +1,60 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package module
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"vsync/core"
+)
+
+func TestKinds(t *testing.T) {
+	h, err := Load(testLock, DefaultConfig())
+	assert.Nil(t, err)
+	defer h.Cleanup()
+
+	assert.Equal(t, []core.AtomicOp{core.RMW, core.Load, core.Store, core.Fence},
+		h.Kinds(core.SelectionAtomic))
+}
+
+func TestLocations(t *testing.T) {
+	h, err := Load(testLock, DefaultConfig())
+	assert.Nil(t, err)
+	defer h.Cleanup()
+
+	// the calls to the atomic functions in the lock
+	locs, err := h.Locations(core.SelectionAtomic)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"testdata/lock.c:7:12",
+		"testdata/lock.c:10:12",
+		"testdata/lock.c:13:5",
+		"testdata/lock.c:16:5",
+	}, locs)
+}
+
+const progNoDebug = `
+@x = dso_local global i32 0, align 4
+
+define dso_local i32 @main() {
+  %1 = atomicrmw add i32* @x, i32 1 seq_cst, align 4
+  ret i32 0
+}
+`
+
+func TestLocationsNoDebug(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "nodebug.ll")
+	assert.Nil(t, os.WriteFile(fn, []byte(progNoDebug), 0600))
+	h, err := Load(fn, DefaultConfig())
+	assert.Nil(t, err)
+	defer h.Cleanup()
+
+	// operations without location would all share the empty one
+	_, err = h.Locations(core.SelectionAtomic)
+	assert.NotNil(t, err)
+}
@@ -22,8 +22,22 @@ func (m *wrapModule) mutate(bs core.Bitseq, sel core.Selection) error {
 	m.Lock()
 	defer m.Unlock()
 
+	changes, err := m.assign(bs, sel)
+	if err != nil {
+		return err
+	}
+	for id, v := range changes {
+		m.imap[id].wrap().after = v
+	}
+	return nil
+}
+
+// assign returns the values of the selected instructions after applying the
+// bitseq on the current mutation. The module is not changed.
+func (m *wrapModule) assign(bs core.Bitseq, sel core.Selection) (snapshot, error) {
 	wi := m.get(sel, true)
 	keys := wi.sortedKeys()
+	changes := make(snapshot, len(keys))
 
 	// assert length of wi and bs match
 	// iterate sorted, apply mutation
@@ -38,19 +52,18 @@ func (m *wrapModule) mutate(bs core.Bitseq, sel core.Selection) error {
 			if !in.isAtomic(true) {
 				logger.Fatal("instruction is not atomic")
 			}
-			in.setOrdering(o)
+			v := in.values(true)
+			v.ordering = o
+			changes[keys[k]] = v
 			return nil
 		})
 	} else {
 		err = bs.Translate(1, func(k int, val int) error {
-			in := wi.get(keys[k])
 			switch val {
 			case 1:
-				in.setAtomic(true)
-				in.setOrdering(core.SeqCst)
+				changes[keys[k]] = wrapValues{atomic: true, ordering: core.SeqCst}
 			case 0:
-				in.setAtomic(false)
-				in.setOrdering(core.Invalid)
+				changes[keys[k]] = wrapValues{atomic: false, ordering: core.Invalid}
 			default:
 				logger.Fatal("unexpected value:", val)
 			}
@@ -58,7 +71,7 @@ func (m *wrapModule) mutate(bs core.Bitseq, sel core.Selection) error {
 		})
 	}
 	if err != nil {
-		return fmt.Errorf("error: %v", err)
+		return nil, fmt.Errorf("error: %v", err)
 	}
-	return nil
+	return changes, nil
 }
@@ -0,0 +1,144 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package module
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/llir/llvm/ir"
+
+	"vsync/core"
+	"vsync/logger"
+)
+
+// Calls to these functions delimit the operations that should be pinned, see
+// include/vsyncer_pin.h.
+const (
+	pinBeginMarker = "__VSYNCER_pin_begin"
+	pinEndMarker   = "__VSYNCER_pin_end"
+)
+
+type pinLoc struct {
+	file string
+	line int64
+}
+
+func parsePinLoc(s string) (pinLoc, error) {
+	idx := strings.LastIndex(s, ":")
+	if idx <= 0 {
+		return pinLoc{}, fmt.Errorf("invalid pin location '%s', expected file:line", s)
+	}
+	line, err := strconv.ParseInt(s[idx+1:], 10, 64)
+	if err != nil {
+		return pinLoc{}, fmt.Errorf("invalid pin location '%s': %v", s, err)
+	}
+	return pinLoc{file: s[:idx], line: line}, nil
+}
+
+func (p pinLoc) match(loc Loc) bool {
+	if loc.Line != p.line {
+		return false
+	}
+	return loc.Filename == p.file || strings.HasSuffix(loc.Filename, "/"+p.file)
+}
+
+// funcChain returns the names of the functions in the call stack of the
+// current thread, ignoring the suffix of expanded functions.
+func funcChain(stack []meta) []string {
+	var names []string
+	for _, m := range stack {
+		var f *ir.Func
+		switch m := m.(type) {
+		case *ir.Func:
+			f = m
+		case *ir.InstCall:
+			if isThreadCreate(m) {
+				names = nil
+			}
+			f = calledFunc(m)
+		default:
+		}
+		if f != nil {
+			names = append(names, sourceFuncName(f.Name()))
+		}
+	}
+	return names
+}
+
+// pinSelected pins the operations selected by the configuration options
+// PinFunc and PinLoc.
+func (m *wrapModule) pinSelected(cfg Config) error {
+	if len(cfg.PinFunc) == 0 && len(cfg.PinLoc) == 0 {
+		return nil
+	}
+	var locs []pinLoc
+	for _, s := range cfg.PinLoc {
+		p, err := parsePinLoc(s)
+		if err != nil {
+			return err
+		}
+		locs = append(locs, p)
+	}
+	funcs := make(map[string]bool)
+	for _, f := range cfg.PinFunc {
+		funcs[f] = true
+	}
+
+	for _, id := range m.imap.sortedKeys() {
+		in := m.imap[id]
+		w := in.wrap()
+		if matchPinFunc(funcs, w.stack) || matchPinLoc(locs, getLoc(w.stack)) {
+			logger.Debugf("pinning %d: %v", id, in.LLString())
+			in.pin()
+		}
+	}
+	return nil
+}
+
+func matchPinFunc(funcs map[string]bool, stack []meta) bool {
+	for _, name := range funcChain(stack) {
+		if funcs[name] {
+			return true
+		}
+	}
+	return false
+}
+
+func matchPinLoc(locs []pinLoc, loc Loc) bool {
+	for _, p := range locs {
+		if p.match(loc) {
+			return true
+		}
+	}
+	return false
+}
+
+// Pinned returns a bitsequence in which the bits of the pinned operations of
+// the selection are set.
+func (m *wrapModule) Pinned(sel core.Selection) core.Bitseq {
+	width := 1
+	if sel.Binary() {
+		width = u2
+	}
+	wi := m.get(sel, true)
+	bs := core.NewBitseq(len(wi) * width)
+	for i, k := range wi.sortedKeys() {
+		if wi.get(k).isPinned() {
+			bs = bs.SetRange(i*width, (i+1)*width-1)
+		}
+	}
+	return bs
+}
+
+func (m *wrapModule) pinned() []wrapInstruction {
+	var insts []wrapInstruction
+	for _, id := range m.imap.sortedKeys() {
+		if in := m.imap[id]; in.isPinned() {
+			insts = append(insts, in)
+		}
+	}
+	return insts
+}
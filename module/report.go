@@ -8,7 +8,6 @@ import (
 
 	"vsync/core"
 	"vsync/logger"
-	"vsync/tools"
 )
 
 // PrintSummary displays at standard output a summary of the module and its recorded mutations.
@@ -16,7 +15,7 @@ func (h *History) PrintSummary() {
 	logger.Println("== SUMMARY ===================================")
 	logger.Println()
 	logger.Println("File")
-	logger.Printf("  %s\n", h.strFiles(0))
+	logger.Printf("  %s\n", h.strFiles())
 	logger.Println()
 	logger.Println("Operations")
 	var (
@@ -37,6 +36,10 @@ func (h *History) PrintSummary() {
 
 	logger.Printf("  RMWs          : %v\n", rmws)
 	logger.Printf("  Fences        : %v\n", fences)
+	insertions := h.count(core.SelectionInsertions, false) > 0
+	if insertions {
+		logger.Printf("  Candidates    : %v\n", h.countDiff(core.SelectionInsertions, 0))
+	}
 	logger.Println()
 
 	logger.Println("Memory ordering")
@@ -57,11 +60,86 @@ func (h *History) PrintSummary() {
 		{"[F] Fences ", core.SelectionFences},
 		{"[X] RMWs   ", core.SelectionRMWs},
 	}
+	if insertions {
+		x = append(x, struct {
+			text  string
+			atype core.Selection
+		}{"[I] Inserts", core.SelectionInsertions})
+	}
 	for _, e := range x {
 		logger.Printf("  %s : %v\n", e.text, h.bitseqDiff(e.atype, 0))
 	}
 	logger.Println()
 
+	h.printPinned()
+}
+
+func (h *History) printPinned() {
+	pinned := h.pinned()
+	if len(pinned) == 0 {
+		return
+	}
+	logger.Println("Pinned")
+	for _, in := range pinned {
+		var (
+			w    = in.wrap()
+			loc  = getLoc(w.stack)
+			fn   = sourceFuncName(w.f.GlobalName)
+			mode = "plain"
+		)
+		if in.isAtomic(true) {
+			mode = withColor(in.getOrdering(true))
+		}
+		logger.Printf("  %s:%d:%d in %s (%s)\n", loc.Filename, loc.Line, loc.Column, fn, mode)
+	}
+	logger.Println()
+}
+
+// PrintThreads displays the number of operations and memory orderings of
+// each thread of the module.
+func (h *History) PrintThreads() {
+	if len(h.threads) == 0 {
+		return
+	}
+	logger.Println("Threads")
+	for i, t := range h.threads {
+		var (
+			count = make(map[core.Selection]int)
+			bc    BarrierCount
+		)
+		for _, in := range h.imap {
+			if !inModule(in) || !in.wrap().executedBy(i) {
+				continue
+			}
+			count[getInstSelection(in, true)]++
+			if in.isAtomic(true) {
+				countBarrier(&bc, in.getOrdering(true))
+			}
+		}
+		logger.Printf("  %s\n", t.Thread)
+		logger.Printf("    Operations : %d plain loads, %d atomic loads, %d plain stores, %d atomic stores, %d RMWs, %d fences\n",
+			count[core.SelectionPlainLoads], count[core.SelectionAtomicLoads],
+			count[core.SelectionPlainStores], count[core.SelectionAtomicStores],
+			count[core.SelectionRMWs], count[core.SelectionFences])
+		logger.Printf("    Orderings  : %d SeqCst, %d Release, %d Acquire, %d Relaxed\n",
+			bc.SeqCst, bc.Release, bc.Acquire, bc.Relaxed)
+	}
+	logger.Println()
+}
+
+// PrintUnreachable displays the atomic operations that are not reached from
+// the entry functions.
+func (h *History) PrintUnreachable() error {
+	ops, err := h.Unreachable()
+	if err != nil || len(ops) == 0 {
+		return err
+	}
+	logger.Println("Unreachable atomics")
+	for _, op := range ops {
+		logger.Printf("  %s in %s (%v %s)\n", op.Loc, op.Func, op.Kind, withColor(op.Ordering))
+	}
+	logger.Println()
+	return nil
 }
 
 // PrintDiff displays the source code difference between the module's initial state and final mutation.
@@ -73,32 +151,13 @@ func (h *History) PrintDiff() error {
 	logger.Println("== CODE DIFF =================================")
 	logger.Println()
 
-	// reload original file and reapply mutations
-	fn := h.hist[0]
-	first, err := loadModule(fn, h.cfg)
-	if err != nil {
-		return err
-	}
-	logger.Debugf("Initial assignment: %v", first.Assignment(core.SelectionAtomic))
-	for _, a := range h.mutations.recorded {
-		logger.Debugf("Target assignment: %v", a)
-		if err := first.mutate(a.Bs, a.Sel); err != nil {
-			return err
-		}
-	}
-	for _, a := range h.mutations.current {
-		logger.Debugf("Target assignment: %v", a)
-		if err := first.mutate(a.Bs, a.Sel); err != nil {
-			return err
-		}
-	}
-	logger.Debugf("Final assignment: %v", first.Assignment(core.SelectionAtomic))
-	err = tools.Dump(first, "something.ll")
-	if err != nil {
-		return err
-	}
+	// compare the initial state with the current mutation
+	var diff []*diffEntry
+	h.withState(h.snaps[0], h.state(true), func() {
+		diff = h.Diff()
+	})
+
 	// print difference
-	diff := first.Diff()
 	for i, d := range diff {
 		if err := printDiffEntry(i, d); err != nil {
 			return fmt.Errorf("cannot print diff: %v", err)
@@ -109,18 +168,21 @@ func (h *History) PrintDiff() error {
 }
 
 func (h *History) countDiff(sel core.Selection, i int) string {
-	if i >= len(h.hist) {
+	if i >= h.length() {
 		return ""
 	}
-	last := i == len(h.hist)-1
+	last := i == h.length()-1
 
 	sep := " \t--> "
 	if i == 0 {
 		sep = ""
 	}
 
-	before := h.mods[i].count(sel, false)
-	after := h.mods[i].count(sel, true)
+	var before, after int
+	h.at(i, func() {
+		before = h.count(sel, false)
+		after = h.count(sel, true)
+	})
 	cur := fmt.Sprintf("%v", before)
 	if last {
 		cur = fmt.Sprintf("%v", after)
@@ -133,10 +195,10 @@ func (h *History) countDiff(sel core.Selection, i int) string {
 }
 
 func (h *History) barrierCountDiff(ordering core.Ordering, i int) string {
-	if i >= len(h.hist) {
+	if i >= h.length() {
 		return ""
 	}
-	last := i == len(h.hist)-1
+	last := i == h.length()-1
 	getVal := func(m *wrapModule) string {
 		bcBefore := m.barrierCount(core.SelectionAtomic, false)
 		bcAfter := m.barrierCount(core.SelectionAtomic, true)
@@ -175,22 +237,29 @@ func (h *History) barrierCountDiff(ordering core.Ordering, i int) string {
 	if i == 0 {
 		sep = ""
 	}
-	return fmt.Sprintf("%s%v%s", sep, getVal(h.mods[i]), h.barrierCountDiff(ordering, i+1))
+	var val string
+	h.at(i, func() {
+		val = getVal(h.wrapModule)
+	})
+	return fmt.Sprintf("%s%v%s", sep, val, h.barrierCountDiff(ordering, i+1))
 }
 
 func (h *History) bitseqDiff(sel core.Selection, i int) string {
-	if i >= len(h.hist) {
+	if i >= h.length() {
 		return ""
 	}
-	last := i == len(h.hist)-1
+	last := i == h.length()-1
 
 	sep := " --> "
 	if i == 0 {
 		sep = ""
 	}
 
-	before := h.mods[i].bitseq(sel, false)
-	after := h.mods[i].bitseq(sel, true)
+	var before, after core.Bitseq
+	h.at(i, func() {
+		before = h.bitseq(sel, false)
+		after = h.bitseq(sel, true)
+	})
 	cur := fmt.Sprintf("%v", before)
 
 	if last {
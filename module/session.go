@@ -0,0 +1,206 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package module
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"vsync/core"
+	"vsync/logger"
+	"vsync/tools"
+)
+
+const sessionVersion = 1
+
+// Session describes how a mutated module is produced from its input module.
+// A session can be saved, loaded and replayed to reproduce the module.
+type Session struct {
+	Version int      `json:"version"`
+	Input   string   `json:"input"`          // the LLVM IR module loaded, relative to the session file when saved
+	Hash    string   `json:"hash"`           // SHA-256 of the input module
+	Args    []string `json:"args,omitempty"` // the command line arguments producing the input
+	Config  Config   `json:"config"`
+	Steps   []Step   `json:"steps"`
+}
+
+// Step contains the mutations of one recorded step of the history.
+type Step struct {
+	Mutations []Mutation `json:"mutations"`
+}
+
+// Mutation is the serialized form of a core.Assignment.
+type Mutation struct {
+	Sel  core.Selection `json:"selection"`
+	Bits string         `json:"bits"` // binary string, keeps the length of the bitseq
+	Hex  string         `json:"hex"`  // for reading only
+}
+
+func newMutation(a core.Assignment) Mutation {
+	return Mutation{
+		Sel:  a.Sel,
+		Bits: "0b" + a.Bs.ToBinString(),
+		Hex:  "0x" + a.Bs.ToHexString(),
+	}
+}
+
+// Assignment returns the assignment of the mutation.
+func (m Mutation) Assignment() (core.Assignment, error) {
+	if m.Bits == "0b" {
+		// selections can be empty after previous mutations
+		return core.Assignment{Bs: core.NewBitseq(0), Sel: m.Sel}, nil
+	}
+	bs, err := core.FromString(m.Bits)
+	if err != nil {
+		return core.Assignment{}, err
+	}
+	return core.Assignment{Bs: bs, Sel: m.Sel}, nil
+}
+
+// Session returns the session of the recorded mutations of the module.
+// Mutations that have not been recorded are not part of the session.
+func (h *History) Session() Session {
+	s := Session{
+		Version: sessionVersion,
+		Input:   h.base,
+		Config:  h.loadCfg,
+		Steps:   []Step{},
+	}
+	if hash, err := fileHash(h.base); err != nil {
+		logger.Warnf("cannot hash '%s': %v", h.base, err)
+	} else {
+		s.Hash = hash
+	}
+	for _, step := range h.mutations.steps {
+		var st Step
+		for _, a := range step {
+			st.Mutations = append(st.Mutations, newMutation(a))
+		}
+		s.Steps = append(s.Steps, st)
+	}
+	return s
+}
+
+// Replay loads the input module of the session and records the first n
+// steps of the session. If n is negative, all steps are replayed.
+func (s Session) Replay(n int) (*History, error) {
+	if n < 0 || n > len(s.Steps) {
+		n = len(s.Steps)
+	}
+	if s.Hash != "" {
+		if hash, err := fileHash(s.Input); err != nil {
+			return nil, err
+		} else if hash != s.Hash {
+			logger.Warnf("input module '%s' changed since the session was saved", s.Input)
+		}
+	}
+	h, err := Load(s.Input, s.Config)
+	if err != nil {
+		return nil, err
+	}
+	for i, st := range s.Steps[:n] {
+		for _, m := range st.Mutations {
+			a, err := m.Assignment()
+			if err != nil {
+				h.Cleanup()
+				return nil, fmt.Errorf("step %d: %v", i, err)
+			}
+			if err := h.Mutate(a); err != nil {
+				h.Cleanup()
+				return nil, fmt.Errorf("step %d: %v", i, err)
+			}
+		}
+		if err := h.Record(); err != nil {
+			h.Cleanup()
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// SaveSession writes the session of the module to a JSON file.
+func (h *History) SaveSession(fn string, args []string) error {
+	s := h.Session()
+	s.Args = args
+	return s.Save(fn)
+}
+
+// Save writes the session to a JSON file. The input module is saved relative
+// to the directory of the session file, so that the session can be replayed
+// from any working directory.
+func (s Session) Save(fn string) error {
+	input, err := tools.RelPath(filepath.Dir(tools.FromSlash(fn)), s.Input)
+	if err != nil {
+		return err
+	}
+	s.Input = input
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	logger.Debugf("Save session '%s'", fn)
+	return ioutil.WriteFile(tools.FromSlash(fn), append(data, '\n'), 0600)
+}
+
+// LoadSession reads a session from a JSON file. The input module of the
+// session is returned as an absolute path.
+func LoadSession(fn string) (Session, error) {
+	var s Session
+	data, err := ioutil.ReadFile(tools.FromSlash(fn))
+	if err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, fmt.Errorf("cannot parse session '%s': %v", fn, err)
+	}
+	if s.Version != sessionVersion {
+		return s, fmt.Errorf("unsupported session version %d", s.Version)
+	}
+	dir, err := filepath.Abs(filepath.Dir(tools.FromSlash(fn)))
+	if err != nil {
+		return s, err
+	}
+	s.Input = tools.ResolvePath(dir, s.Input)
+	return s, nil
+}
+
+// SessionExists returns true if the session file exists.
+func SessionExists(fn string) bool {
+	_, err := os.Stat(tools.FromSlash(fn))
+	return err == nil
+}
+
+// Undo drops the last recorded step of the history and any mutation not
+// recorded yet.
+func (h *History) Undo() error {
+	if len(h.mutations.steps) == 0 {
+		return errors.New("nothing to undo")
+	}
+	h.Lock()
+	defer h.Unlock()
+	h.snaps = h.snaps[:len(h.snaps)-1]
+	s := h.snaps[len(h.snaps)-1]
+	h.setState(s, s)
+
+	last := h.mutations.steps[len(h.mutations.steps)-1]
+	h.mutations.steps = h.mutations.steps[:len(h.mutations.steps)-1]
+	h.mutations.recorded = h.mutations.recorded[:len(h.mutations.recorded)-len(last)]
+	h.clearMut()
+	return nil
+}
+
+func fileHash(fn string) (string, error) {
+	data, err := ioutil.ReadFile(tools.FromSlash(fn))
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
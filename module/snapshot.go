@@ -0,0 +1,55 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package module
+
+// snapshot holds the orderings and atomic flags of the instructions of a
+// module indexed by instruction id.
+type snapshot map[int]wrapValues
+
+// state returns a snapshot of the instructions before or after the mutation.
+func (m *wrapModule) state(after bool) snapshot {
+	s := make(snapshot, len(m.imap))
+	for id, in := range m.imap {
+		s[id] = in.values(after)
+	}
+	return s
+}
+
+// setState sets the instructions to the given snapshots, the LLVM
+// instructions reflect the before state.
+func (m *wrapModule) setState(before, after snapshot) {
+	for id, in := range m.imap {
+		w := in.wrap()
+		w.before = before[id]
+		w.after = after[id]
+		in.apply(w.before)
+	}
+}
+
+// commit makes the mutations of all instructions their initial state.
+func (m *wrapModule) commit() {
+	for _, in := range m.imap {
+		in.wrap().commit()
+		in.apply(in.values(false))
+	}
+}
+
+// rollback drops the mutations of all instructions.
+func (m *wrapModule) rollback() {
+	for _, in := range m.imap {
+		w := in.wrap()
+		w.after = w.before
+	}
+}
+
+// withState temporarily sets the module to the given snapshots while calling fn.
+func (m *wrapModule) withState(before, after snapshot, fn func()) {
+	var (
+		b = m.state(false)
+		a = m.state(true)
+	)
+	m.setState(before, after)
+	defer m.setState(b, a)
+	fn()
+}
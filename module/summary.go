@@ -0,0 +1,103 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package module
+
+import (
+	"fmt"
+
+	"vsync/core"
+)
+
+// SummarySection is a table of the summary. Each row has a value per
+// recorded mutation, the last value is the current mutation.
+type SummarySection struct {
+	Title string
+	Rows  []SummaryRow
+}
+
+// SummaryRow is a row of a summary table.
+type SummaryRow struct {
+	Name   string
+	Values []string
+}
+
+// Summary returns the tables shown by PrintSummary without colors.
+func (h *History) Summary() []SummarySection {
+	count := func(sel core.Selection) []string {
+		return h.steps(func(after bool) string {
+			return fmt.Sprint(h.count(sel, after))
+		})
+	}
+	barrier := func(get func(bc BarrierCount) int) []string {
+		return h.steps(func(after bool) string {
+			return fmt.Sprint(get(h.barrierCount(core.SelectionAtomic, after)))
+		})
+	}
+	bitseq := func(sel core.Selection) []string {
+		return h.steps(func(after bool) string {
+			return fmt.Sprint(h.bitseq(sel, after))
+		})
+	}
+
+	files := []string{h.name}
+	for i := 1; i < h.length(); i++ {
+		files = append(files, fmt.Sprintf("#%d", i))
+	}
+	ops := SummarySection{Title: "Operations", Rows: []SummaryRow{
+		{"Plain loads", count(core.SelectionPlainLoads)},
+		{"Atomic loads", count(core.SelectionAtomicLoads)},
+		{"Plain stores", count(core.SelectionPlainStores)},
+		{"Atomic stores", count(core.SelectionAtomicStores)},
+		{"RMWs", count(core.SelectionRMWs)},
+		{"Fences", count(core.SelectionFences)},
+	}}
+	assignments := SummarySection{Title: "Assignments", Rows: []SummaryRow{
+		{"[L] Loads", bitseq(core.SelectionLoads)},
+		{"[S] Stores", bitseq(core.SelectionStores)},
+		{"[A] Atomics", bitseq(core.SelectionAtomic)},
+		{"[F] Fences", bitseq(core.SelectionFences)},
+		{"[X] RMWs", bitseq(core.SelectionRMWs)},
+	}}
+	if h.count(core.SelectionInsertions, false) > 0 {
+		ops.Rows = append(ops.Rows, SummaryRow{"Candidates", count(core.SelectionInsertions)})
+		assignments.Rows = append(assignments.Rows, SummaryRow{"[I] Inserts", bitseq(core.SelectionInsertions)})
+	}
+
+	return []SummarySection{
+		{Title: "File", Rows: []SummaryRow{{"File", files}}},
+		ops,
+		{Title: "Memory ordering", Rows: []SummaryRow{
+			{"SeqCst", barrier(func(bc BarrierCount) int { return bc.SeqCst })},
+			{"Release", barrier(func(bc BarrierCount) int { return bc.Release })},
+			{"Acquire", barrier(func(bc BarrierCount) int { return bc.Acquire })},
+			{"Relaxed", barrier(func(bc BarrierCount) int { return bc.Relaxed })},
+		}},
+		assignments,
+	}
+}
+
+// steps returns a value for each recorded mutation, see countDiff.
+func (h *History) steps(val func(after bool) string) []string {
+	var vals []string
+	for i := 0; i < h.length(); i++ {
+		last := i == h.length()-1
+		h.at(i, func() {
+			vals = append(vals, val(last))
+		})
+	}
+	return vals
+}
+
+// InitialOperations returns the tracked operations of the module as loaded,
+// see Operations.
+func (h *History) InitialOperations() []Operation {
+	h.Lock()
+	defer h.Unlock()
+
+	var ops []Operation
+	h.withState(h.snaps[0], h.snaps[0], func() {
+		ops = h.operations()
+	})
+	return ops
+}
@@ -0,0 +1,39 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package module
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"vsync/core"
+)
+
+func TestSummary(t *testing.T) {
+	h, err := Load(testLock, DefaultConfig())
+	assert.Nil(t, err)
+	defer h.Cleanup()
+
+	assert.Nil(t, h.Record())
+	a := core.Assignment{Bs: core.MustFromBinString("00011001"), Sel: core.SelectionAtomic}
+	assert.Nil(t, h.Mutate(a))
+
+	sections := h.Summary()
+	assert.Len(t, sections, 4)
+	assert.Equal(t, "#1", sections[0].Rows[0].Values[1])
+	assert.Equal(t, "Memory ordering", sections[2].Title)
+	assert.Equal(t, SummaryRow{"SeqCst", []string{"4", "0"}}, sections[2].Rows[0])
+	assert.Equal(t, SummaryRow{"Release", []string{"0", "2"}}, sections[2].Rows[1])
+	assert.Equal(t, SummaryRow{"[A] Atomics", []string{"0xff (0b11111111)", "0x19 (0b00011001)"}}, sections[3].Rows[2])
+
+	// the initial operations are not affected by the mutation
+	before, after := h.InitialOperations(), h.Operations()
+	assert.Len(t, before, 7)
+	assert.Len(t, after, 7)
+	assert.Equal(t, core.SeqCst, before[0].Ordering)
+	assert.Equal(t, core.Release, after[0].Ordering)
+	assert.Equal(t, core.SeqCst, before[5].Ordering)
+	assert.Equal(t, core.Relaxed, after[5].Ordering)
+}
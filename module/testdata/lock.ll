@@ -0,0 +1,127 @@
+; ModuleID = 'lock.c'
+source_filename = "lock.c"
+target datalayout = "e-m:e-p270:32:32-p271:32:32-p272:64:64-i64:64-f80:128-n8:16:32:64-S128"
+target triple = "x86_64-pc-linux-gnu"
+
+@lock = dso_local global i32 0, align 4, !dbg !0
+@cnt = dso_local global i32 0, align 4, !dbg !5
+
+define internal i32 @vatomic32_xchg(i32* noundef %0, i32 noundef %1) #0 !dbg !15 {
+  %3 = atomicrmw xchg i32* %0, i32 %1 seq_cst, align 4, !dbg !16
+  ret i32 %3, !dbg !16
+}
+
+define internal i32 @vatomic32_read(i32* noundef %0) #0 !dbg !17 {
+  %2 = load atomic i32, i32* %0 seq_cst, align 4, !dbg !18
+  ret i32 %2, !dbg !18
+}
+
+define internal void @vatomic32_write(i32* noundef %0, i32 noundef %1) #0 !dbg !19 {
+  store atomic i32 %1, i32* %0 seq_cst, align 4, !dbg !20
+  ret void, !dbg !20
+}
+
+define internal void @vatomic_fence() #0 !dbg !21 {
+  fence seq_cst, !dbg !22
+  ret void, !dbg !22
+}
+
+define dso_local void @acquire() #0 !dbg !23 {
+  br label %1, !dbg !24
+
+1:
+  %2 = call i32 @vatomic32_xchg(i32* noundef @lock, i32 noundef 1), !dbg !24
+  %3 = icmp ne i32 %2, 0, !dbg !24
+  br i1 %3, label %4, label %6, !dbg !24
+
+4:
+  %5 = call i32 @vatomic32_read(i32* noundef @lock), !dbg !25
+  br label %1, !dbg !25
+
+6:
+  ret void, !dbg !26
+}
+
+define dso_local void @release() #0 !dbg !27 {
+  call void @vatomic32_write(i32* noundef @lock, i32 noundef 0), !dbg !28
+  ret void, !dbg !29
+}
+
+define dso_local i8* @run(i8* noundef %0) #0 !dbg !30 {
+  call void @acquire(), !dbg !31
+  %2 = load i32, i32* @cnt, align 4, !dbg !32
+  %3 = add nsw i32 %2, 1, !dbg !32
+  store i32 %3, i32* @cnt, align 4, !dbg !32
+  call void @release(), !dbg !33
+  call void @vatomic_fence(), !dbg !34
+  ret i8* null, !dbg !35
+}
+
+define dso_local i32 @main() #0 !dbg !36 {
+  %1 = alloca i64, align 8
+  %2 = alloca i64, align 8
+  %3 = call i32 @pthread_create(i64* noundef %1, i8* noundef null, i8* (i8*)* noundef @run, i8* noundef null), !dbg !37
+  %4 = call i32 @pthread_create(i64* noundef %2, i8* noundef null, i8* (i8*)* noundef @run, i8* noundef null), !dbg !38
+  %5 = call i8* @run(i8* noundef null), !dbg !39
+  %6 = load i64, i64* %1, align 8, !dbg !40
+  %7 = call i32 @pthread_join(i64 noundef %6, i8** noundef null), !dbg !40
+  %8 = load i64, i64* %2, align 8, !dbg !41
+  %9 = call i32 @pthread_join(i64 noundef %8, i8** noundef null), !dbg !41
+  %10 = load i32, i32* @cnt, align 4, !dbg !42
+  ret i32 %10, !dbg !43
+}
+
+declare i32 @pthread_create(i64* noundef, i8* noundef, i8* (i8*)* noundef, i8* noundef) #1
+
+declare i32 @pthread_join(i64 noundef, i8** noundef) #1
+
+attributes #0 = { noinline nounwind uwtable }
+attributes #1 = { nounwind }
+
+!llvm.dbg.cu = !{!2}
+!llvm.module.flags = !{!10, !11, !12}
+!llvm.ident = !{!14}
+
+!0 = !DIGlobalVariableExpression(var: !1, expr: !DIExpression())
+!1 = distinct !DIGlobalVariable(name: "lock", scope: !2, file: !3, line: 3, type: !8, isLocal: false, isDefinition: true)
+!2 = distinct !DICompileUnit(language: DW_LANG_C99, file: !3, producer: "clang version 14.0.6", isOptimized: false, runtimeVersion: 0, emissionKind: FullDebug, globals: !4, splitDebugInlining: false, nameTableKind: None)
+!3 = !DIFile(filename: "lock.c", directory: "testdata")
+!4 = !{!0, !5}
+!5 = !DIGlobalVariableExpression(var: !6, expr: !DIExpression())
+!6 = distinct !DIGlobalVariable(name: "cnt", scope: !2, file: !3, line: 4, type: !8, isLocal: false, isDefinition: true)
+!7 = !DISubroutineType(types: !9)
+!8 = !DIBasicType(name: "int", size: 32, encoding: DW_ATE_signed)
+!9 = !{}
+!10 = !{i32 7, !"Dwarf Version", i32 5}
+!11 = !{i32 2, !"Debug Info Version", i32 3}
+!12 = !{i32 1, !"wchar_size", i32 4}
+!14 = !{!"clang version 14.0.6"}
+!15 = distinct !DISubprogram(name: "vatomic32_xchg", scope: !3, file: !3, line: 6, type: !7, scopeLine: 6, spFlags: DISPFlagDefinition, unit: !2, retainedNodes: !9)
+!16 = !DILocation(line: 7, column: 12, scope: !15)
+!17 = distinct !DISubprogram(name: "vatomic32_read", scope: !3, file: !3, line: 9, type: !7, scopeLine: 9, spFlags: DISPFlagDefinition, unit: !2, retainedNodes: !9)
+!18 = !DILocation(line: 10, column: 12, scope: !17)
+!19 = distinct !DISubprogram(name: "vatomic32_write", scope: !3, file: !3, line: 12, type: !7, scopeLine: 12, spFlags: DISPFlagDefinition, unit: !2, retainedNodes: !9)
+!20 = !DILocation(line: 13, column: 5, scope: !19)
+!21 = distinct !DISubprogram(name: "vatomic_fence", scope: !3, file: !3, line: 15, type: !7, scopeLine: 15, spFlags: DISPFlagDefinition, unit: !2, retainedNodes: !9)
+!22 = !DILocation(line: 16, column: 5, scope: !21)
+!23 = distinct !DISubprogram(name: "acquire", scope: !3, file: !3, line: 18, type: !7, scopeLine: 18, spFlags: DISPFlagDefinition, unit: !2, retainedNodes: !9)
+!24 = !DILocation(line: 19, column: 12, scope: !23)
+!25 = !DILocation(line: 20, column: 9, scope: !23)
+!26 = !DILocation(line: 21, column: 1, scope: !23)
+!27 = distinct !DISubprogram(name: "release", scope: !3, file: !3, line: 23, type: !7, scopeLine: 23, spFlags: DISPFlagDefinition, unit: !2, retainedNodes: !9)
+!28 = !DILocation(line: 24, column: 5, scope: !27)
+!29 = !DILocation(line: 25, column: 1, scope: !27)
+!30 = distinct !DISubprogram(name: "run", scope: !3, file: !3, line: 27, type: !7, scopeLine: 27, spFlags: DISPFlagDefinition, unit: !2, retainedNodes: !9)
+!31 = !DILocation(line: 28, column: 5, scope: !30)
+!32 = !DILocation(line: 29, column: 9, scope: !30)
+!33 = !DILocation(line: 30, column: 5, scope: !30)
+!34 = !DILocation(line: 31, column: 5, scope: !30)
+!35 = !DILocation(line: 32, column: 5, scope: !30)
+!36 = distinct !DISubprogram(name: "main", scope: !3, file: !3, line: 35, type: !7, scopeLine: 35, spFlags: DISPFlagDefinition, unit: !2, retainedNodes: !9)
+!37 = !DILocation(line: 37, column: 5, scope: !36)
+!38 = !DILocation(line: 38, column: 5, scope: !36)
+!39 = !DILocation(line: 39, column: 5, scope: !36)
+!40 = !DILocation(line: 40, column: 5, scope: !36)
+!41 = !DILocation(line: 41, column: 5, scope: !36)
+!42 = !DILocation(line: 42, column: 12, scope: !36)
+!43 = !DILocation(line: 42, column: 5, scope: !36)
@@ -0,0 +1,163 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package module
+
+import (
+	"fmt"
+
+	"github.com/llir/llvm/ir"
+)
+
+// Thread is a thread entry of the module, ie, an entry function or a thread
+// function together with the call that creates the thread.
+type Thread struct {
+	Func string // function executed by the thread
+	Site Loc    // location of the thread creation, empty for entry functions
+}
+
+func (t Thread) String() string {
+	if t.Site == (Loc{}) {
+		return t.Func
+	}
+	return fmt.Sprintf("%s (created at %s)", t.Func, t.Site)
+}
+
+// threadEntry is a thread of the module with the functions it may execute.
+type threadEntry struct {
+	Thread
+	funcs map[*ir.Func]bool
+}
+
+// findThreads determines the threads of the module starting from the entry
+// functions. Every thread creation reached by a thread is a new thread entry.
+// Calls to functions matching the skip prefixes are not followed.
+func (m *wrapModule) findThreads(cfg Config) []*threadEntry {
+	var (
+		threads []*threadEntry
+		created = make(map[*ir.InstCall]bool)
+	)
+	for _, name := range cfg.EntryFunc {
+		for _, f := range m.Funcs {
+			if f.Name() == name {
+				threads = append(threads, &threadEntry{
+					Thread: Thread{Func: name},
+					funcs:  map[*ir.Func]bool{f: true},
+				})
+			}
+		}
+	}
+
+	// threads are appended while walking the call graph of each thread
+	for i := 0; i < len(threads); i++ {
+		t := threads[i]
+		var queue []*ir.Func
+		for f := range t.funcs {
+			queue = append(queue, f)
+		}
+		for len(queue) > 0 {
+			f := queue[0]
+			queue = queue[1:]
+			for _, block := range f.Blocks {
+				for _, inst := range block.Insts {
+					call, ok := inst.(*ir.InstCall)
+					if !ok {
+						continue
+					}
+					callee := calledFunc(call)
+					switch {
+					case callee == nil:
+					case isThreadCreate(call):
+						if created[call] {
+							continue
+						}
+						created[call] = true
+						threads = append(threads, &threadEntry{
+							Thread: Thread{
+								Func: sourceFuncName(callee.Name()),
+								Site: getLoc([]meta{f, call}),
+							},
+							funcs: map[*ir.Func]bool{callee: true},
+						})
+					case isSkipped(callee.Name(), cfg.SkipFuncPref):
+					case !t.funcs[callee]:
+						t.funcs[callee] = true
+						queue = append(queue, callee)
+					}
+				}
+			}
+		}
+	}
+	return threads
+}
+
+// annotateThreads sets the threads that may execute each instruction.
+func (m *wrapModule) annotateThreads(cfg Config) {
+	m.threads = m.findThreads(cfg)
+	for _, in := range m.imap {
+		w := in.wrap()
+		for i, t := range m.threads {
+			if t.funcs[w.f] {
+				w.threads = append(w.threads, i)
+			}
+		}
+	}
+}
+
+// executedBy returns true if the instruction may be executed by the thread
+// with the given index.
+func (w *wrapInst) executedBy(thread int) bool {
+	for _, i := range w.threads {
+		if i == thread {
+			return true
+		}
+	}
+	return false
+}
+
+// Threads returns the threads of the module, the entry functions first.
+func (m *wrapModule) Threads() []Thread {
+	threads := make([]Thread, len(m.threads))
+	for i, t := range m.threads {
+		threads[i] = t.Thread
+	}
+	return threads
+}
+
+// Operations returns the tracked operations of the module in id order with
+// their current ordering and the threads executing them.
+func (h *History) Operations() []Operation {
+	h.Lock()
+	defer h.Unlock()
+	return h.operations()
+}
+
+func (m *wrapModule) operations() []Operation {
+	var ops []Operation
+	for _, id := range m.imap.sortedKeys() {
+		in := m.imap[id]
+		if !inModule(in) {
+			continue
+		}
+		ops = append(ops, m.operation(id, in))
+	}
+	return ops
+}
+
+func (m *wrapModule) operation(id int, in wrapInstruction) Operation {
+	w := in.wrap()
+	op := Operation{
+		ID:     id,
+		Kind:   mapInstruction(in),
+		Func:   sourceFuncName(w.f.Name()),
+		Loc:    getLoc(w.stack),
+		Atomic: w.after.atomic,
+	}
+	if op.Atomic {
+		op.Ordering = w.after.ordering
+	}
+	for _, i := range w.threads {
+		op.Threads = append(op.Threads, m.threads[i].Thread)
+	}
+	return op
+}
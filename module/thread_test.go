@@ -0,0 +1,40 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package module
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"vsync/core"
+)
+
+func TestThreads(t *testing.T) {
+	h, err := Load(testLock, DefaultConfig())
+	assert.Nil(t, err)
+	defer h.Cleanup()
+
+	var (
+		main = Thread{Func: "main"}
+		t1   = Thread{Func: "run", Site: Loc{Filename: "testdata/lock.c", Directory: "testdata", Line: 37, Column: 5}}
+		t2   = Thread{Func: "run", Site: Loc{Filename: "testdata/lock.c", Directory: "testdata", Line: 38, Column: 5}}
+	)
+	assert.Equal(t, []Thread{main, t1, t2}, h.Threads())
+
+	ops := h.Operations()
+	assert.Len(t, ops, 7)
+	for _, op := range ops {
+		switch op.Func {
+		case "main":
+			// the final read of the counter
+			assert.Equal(t, core.Load, op.Kind)
+			assert.False(t, op.Atomic)
+			assert.Equal(t, []Thread{main}, op.Threads)
+		default:
+			// run is called by main and by the created threads
+			assert.Equal(t, []Thread{main, t1, t2}, op.Threads, op.Func)
+		}
+	}
+}
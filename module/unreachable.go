@@ -0,0 +1,131 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package module
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/enum"
+
+	"vsync/core"
+)
+
+// Operation is a memory operation of the module.
+type Operation struct {
+	ID       int // instruction id, 0 if the operation is not tracked
+	Kind     core.AtomicOp
+	Atomic   bool
+	Ordering core.Ordering // ordering of atomic operations
+	Func     string        // enclosing function as in the source code
+	Loc      Loc
+	Threads  []Thread // threads that may execute the operation
+}
+
+// Unreachable returns the atomic operations of the module that are not
+// reached from the entry functions. These operations are not part of any
+// selection and hence never verified. Functions matching the skip prefixes
+// and the originals of expanded functions are ignored.
+func (h *History) Unreachable() ([]Operation, error) {
+	h.Lock()
+	defer h.Unlock()
+
+	// visit the module once more to collect the reached instructions that
+	// were not wrapped, eg, atomics without debug information
+	reached := make(map[ir.Instruction]bool)
+	err := h.Visit(h.cfg.EntryFunc, func(i ir.Instruction, _ *ir.Func, _ []meta) ir.Instruction {
+		reached[i] = true
+		return nil
+	}, h.cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var ops []Operation
+	for _, f := range h.Funcs {
+		if isSkipped(f.Name(), h.cfg.SkipFuncPref) || h.isExpanded(f) {
+			continue
+		}
+		for _, block := range f.Blocks {
+			for _, inst := range block.Insts {
+				if reached[inst] {
+					continue
+				}
+				if op, ok := atomicOperation(inst, f); ok {
+					ops = append(ops, op)
+				}
+			}
+		}
+	}
+	sort.SliceStable(ops, func(i, j int) bool {
+		a, b := ops[i].Loc, ops[j].Loc
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return ops, nil
+}
+
+// isExpanded returns true if the function was cloned by the expansion. The
+// calls reached from the entry functions then target the clones.
+func (m *wrapModule) isExpanded(f *ir.Func) bool {
+	prefix := f.Name() + "__vsyncer_expand_"
+	for _, g := range m.Funcs {
+		if strings.HasPrefix(g.Name(), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func isSkipped(name string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.Contains(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// atomicOperation returns the operation of an atomic instruction. Wrapped
+// instructions are reached and therefore ignored.
+func atomicOperation(inst ir.Instruction, f *ir.Func) (Operation, bool) {
+	var (
+		kind core.AtomicOp
+		ao   enum.AtomicOrdering
+		md   meta
+	)
+	switch inst := inst.(type) {
+	case *ir.InstLoad:
+		if !inst.Atomic {
+			return Operation{}, false
+		}
+		kind, ao, md = core.Load, inst.Ordering, inst
+	case *ir.InstStore:
+		if !inst.Atomic {
+			return Operation{}, false
+		}
+		kind, ao, md = core.Store, inst.Ordering, inst
+	case *ir.InstFence:
+		kind, ao, md = core.Fence, inst.Ordering, inst
+	case *ir.InstAtomicRMW:
+		kind, ao, md = core.RMW, inst.Ordering, inst
+	case *ir.InstCmpXchg:
+		kind, ao, md = core.Cmpxchg, inst.SuccessOrdering, inst
+	default:
+		return Operation{}, false
+	}
+	return Operation{
+		Kind:     kind,
+		Atomic:   true,
+		Ordering: fromAtomicOrdering(ao),
+		Func:     sourceFuncName(f.Name()),
+		Loc:      getLoc([]meta{f, md}),
+	}, true
+}
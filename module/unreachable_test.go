@@ -0,0 +1,51 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package module
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"vsync/core"
+)
+
+func TestUnreachable(t *testing.T) {
+	for _, expand := range []bool{true, false} {
+		cfg := DefaultConfig()
+		cfg.Expand = expand
+
+		// all atomics of the lock are reached from main
+		h, err := Load(testLock, cfg)
+		assert.Nil(t, err)
+		ops, err := h.Unreachable()
+		assert.Nil(t, err)
+		assert.Empty(t, ops)
+		h.Cleanup()
+
+		h, err = Load("testdata/unreachable.ll", cfg)
+		assert.Nil(t, err)
+		ops, err = h.Unreachable()
+		assert.Nil(t, err)
+		assert.Equal(t, []Operation{
+			{
+				Kind:     core.Cmpxchg,
+				Atomic:   true,
+				Ordering: core.Acquire,
+				Func:     "try_acquire",
+				Loc:      Loc{Filename: "testdata/unreachable.c", Directory: "testdata", Line: 46, Column: 12},
+			},
+			{
+				Kind:     core.Store,
+				Atomic:   true,
+				Ordering: core.Release,
+				Func:     "reset",
+				Loc:      Loc{Filename: "testdata/unreachable.c", Directory: "testdata", Line: 50, Column: 5},
+			},
+		}, ops)
+		// the unreachable atomics are not part of the selection
+		assert.Equal(t, "11111111", h.Assignment(core.SelectionAtomic).Bs.ToBinString())
+		h.Cleanup()
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package module
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"vsync/core"
+	"vsync/logger"
+)
+
+// View is an immutable rendering of a module with an assignment applied to
+// its current mutation. Views do not share mutable state with the module and
+// can be printed concurrently, eg, by checkers running in parallel.
+type View struct {
+	tmpl  *template
+	insts map[int]string
+	a     core.Assignment
+}
+
+// template is the text of a module in which the wrapped instructions are
+// replaced by placeholders. Since only the wrapped instructions change with
+// mutations, the template remains valid for the lifetime of the module.
+type template struct {
+	text []string // text[i] precedes the instruction ids[i]
+	ids  []int
+}
+
+const markerSep = "\x00"
+
+// View returns an immutable view of the module with the assignment applied
+// on top of the current mutation. The module itself is not changed.
+func (h *History) View(a core.Assignment) (*View, error) {
+	h.Lock()
+	defer h.Unlock()
+
+	changes, err := h.assign(a.Bs, a.Sel)
+	if err != nil {
+		return nil, err
+	}
+	tmpl := h.template()
+	insts := make(map[int]string, len(tmpl.ids))
+	for _, id := range tmpl.ids {
+		in := h.imap[id]
+		v, has := changes[id]
+		if !has {
+			v = in.values(true)
+		}
+		insts[id] = in.render(v)
+	}
+	return &View{tmpl: tmpl, insts: insts, a: a}, nil
+}
+
+// Assignment returns the assignment applied in the view.
+func (v *View) Assignment() core.Assignment {
+	return v.a
+}
+
+func (v *View) String() string {
+	var sb strings.Builder
+	for i, id := range v.tmpl.ids {
+		sb.WriteString(v.tmpl.text[i])
+		sb.WriteString(v.insts[id])
+	}
+	sb.WriteString(v.tmpl.text[len(v.tmpl.ids)])
+	return sb.String()
+}
+
+// template returns the template of the module, creating it on the first
+// call. The caller must hold the lock of the module.
+func (m *wrapModule) template() *template {
+	if m.tmpl != nil {
+		return m.tmpl
+	}
+	for id, in := range m.imap {
+		in.wrap().marker = fmt.Sprintf("%s%d%s", markerSep, id, markerSep)
+	}
+	text := m.Module.String()
+	for _, in := range m.imap {
+		in.wrap().marker = ""
+	}
+
+	// the parts alternate between text and instruction ids
+	parts := strings.Split(text, markerSep)
+	t := new(template)
+	for i, p := range parts {
+		if i%2 == 0 {
+			t.text = append(t.text, p)
+			continue
+		}
+		id, err := strconv.Atoi(p)
+		if err != nil {
+			logger.Fatalf("invalid template marker '%s'", p)
+		}
+		t.ids = append(t.ids, id)
+	}
+	m.tmpl = t
+	return t
+}
@@ -0,0 +1,120 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package module
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"vsync/core"
+)
+
+var viewBitseqs = []string{
+	"11111111", "00000000", "01011001", "10001011", "00000001", "11110000",
+}
+
+// expected returns the module as printed after mutating it with a.
+func expected(t *testing.T, h *History, a core.Assignment) string {
+	assert.Nil(t, h.Mutate(a))
+	s := h.String()
+	assert.Nil(t, h.Forget())
+	return s
+}
+
+func TestViewString(t *testing.T) {
+	h, err := Load(testLock, DefaultConfig())
+	assert.Nil(t, err)
+	defer h.Cleanup()
+
+	initial := h.String()
+	for _, b := range viewBitseqs {
+		a := core.Assignment{Bs: core.MustFromBinString(b), Sel: core.SelectionAtomic}
+		v, err := h.View(a)
+		assert.Nil(t, err)
+		assert.Equal(t, expected(t, h, a), v.String(), b)
+		assert.Equal(t, a, v.Assignment())
+	}
+	// views do not change the module
+	assert.Equal(t, initial, h.String())
+
+	// the views are based on the recorded and current mutations
+	assert.Nil(t, h.Mutate(core.Assignment{Bs: core.MustFromBinString("011"), Sel: core.SelectionLoads}))
+	assert.Nil(t, h.Record())
+	a := core.Assignment{Bs: core.MustFromBinString("0101101001"), Sel: core.SelectionAtomic}
+	v, err := h.View(a)
+	assert.Nil(t, err)
+	assert.Equal(t, expected(t, h, a), v.String())
+
+	// invalid orderings are reported
+	_, err = h.View(core.Assignment{Bs: core.MustFromBinString("0101100101"), Sel: core.SelectionAtomic})
+	assert.NotNil(t, err)
+}
+
+func TestViewConcurrent(t *testing.T) {
+	h, err := Load(testLock, DefaultConfig())
+	assert.Nil(t, err)
+	defer h.Cleanup()
+
+	var want []string
+	for _, b := range viewBitseqs {
+		a := core.Assignment{Bs: core.MustFromBinString(b), Sel: core.SelectionAtomic}
+		want = append(want, expected(t, h, a))
+	}
+
+	const rounds = 8
+	var (
+		wg  sync.WaitGroup
+		got = make([][]string, rounds)
+	)
+	for r := 0; r < rounds; r++ {
+		got[r] = make([]string, len(viewBitseqs))
+		for i, b := range viewBitseqs {
+			wg.Add(1)
+			go func(r, i int, b string) {
+				defer wg.Done()
+				a := core.Assignment{Bs: core.MustFromBinString(b), Sel: core.SelectionAtomic}
+				v, err := h.View(a)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				got[r][i] = v.String()
+			}(r, i, b)
+		}
+	}
+	wg.Wait()
+
+	for r := 0; r < rounds; r++ {
+		assert.Equal(t, want, got[r])
+	}
+}
+
+func TestViewShared(t *testing.T) {
+	h, err := Load(testLock, DefaultConfig())
+	assert.Nil(t, err)
+	defer h.Cleanup()
+
+	a := core.Assignment{Bs: core.MustFromBinString("01011001"), Sel: core.SelectionAtomic}
+	want := expected(t, h, a)
+	v, err := h.View(a)
+	assert.Nil(t, err)
+
+	// one view printed by several goroutines while the module is mutated
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, want, v.String())
+		}()
+	}
+	for _, b := range viewBitseqs {
+		a := core.Assignment{Bs: core.MustFromBinString(b), Sel: core.SelectionAtomic}
+		assert.Nil(t, h.Mutate(a))
+		assert.Nil(t, h.Forget())
+	}
+	wg.Wait()
+}
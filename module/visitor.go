@@ -10,6 +10,7 @@ import (
 	"github.com/llir/llvm/ir"
 	"github.com/llir/llvm/ir/constant"
 	"github.com/llir/llvm/ir/metadata"
+	"github.com/llir/llvm/ir/value"
 
 	"vsync/logger"
 )
@@ -142,6 +143,44 @@ func (v *visitor) visitCallee(inst *ir.InstCall, f *ir.Func, stack []meta, cb Vi
 	return err
 }
 
+// isThreadCreate returns whether a call instruction creates a thread.
+func isThreadCreate(inst *ir.InstCall) bool {
+	callee := inst.Callee.Ident()
+	return strings.Contains(callee, "pthread_create") ||
+		strings.Contains(callee, "__VERIFIER_thread_create")
+}
+
+// calledFunc returns the function entered by a call instruction. For thread
+// creation calls, that is the thread function. If the function cannot be
+// determined, nil is returned.
+func calledFunc(inst *ir.InstCall) *ir.Func {
+	var (
+		callee = inst.Callee.Ident()
+		ops    = inst.Operands()
+		target value.Value
+	)
+	switch {
+	case strings.Contains(callee, "pthread_create") && len(ops) > 3:
+		target = *ops[3]
+	case strings.Contains(callee, "__VERIFIER_thread_create") && len(ops) > 2:
+		target = *ops[2]
+	default:
+		target = inst.Callee
+	}
+	switch t := target.(type) {
+	case *ir.Func:
+		return t
+	case *ir.Arg:
+		f, _ := t.Value.(*ir.Func)
+		return f
+	case *constant.ExprBitCast:
+		f, _ := t.From.(*ir.Func)
+		return f
+	default:
+		return nil
+	}
+}
+
 func (v *visitor) visitInst(inst ir.Instruction, f *ir.Func,
 	stack []meta, cb VisitCallback) (bool, ir.Instruction, error) {
 	if v.visited[inst] {
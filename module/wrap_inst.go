@@ -16,16 +16,21 @@ type wrapValues struct {
 	atomic   bool
 }
 type wrapInst struct {
-	f      *ir.Func
-	inst   ir.Instruction
-	before wrapValues
-	after  wrapValues
-	stack  []meta
-	id     int
+	f       *ir.Func
+	inst    ir.Instruction
+	before  wrapValues
+	after   wrapValues
+	stack   []meta
+	id      int
+	pinned  bool
+	threads []int  // indices of the threads executing the instruction
+	marker  string // printed instead of the instruction when set, see template
 }
 
 func (w *wrapInst) wrapID() int { return w.id }
 
+func (w *wrapInst) wrap() *wrapInst { return w }
+
 func newWrap(inst ir.Instruction, before wrapValues, f *ir.Func, s []meta, id int) wrapInst {
 	if verboseVisitor {
 		logger.Debugf("adding %d: %v", id, inst.LLString())
@@ -42,13 +47,6 @@ func newWrap(inst ir.Instruction, before wrapValues, f *ir.Func, s []meta, id in
 	}
 }
 
-func (w *wrapInst) setOrdering(o core.Ordering) {
-	w.after.ordering = o
-}
-
-func (w *wrapInst) setAtomic(a bool) {
-	w.after.atomic = a
-}
 func (w *wrapInst) isMutation() bool {
 	return w.before != w.after
 }
@@ -64,23 +62,58 @@ func (w *wrapInst) getOrdering(after bool) core.Ordering {
 	}
 	return w.before.ordering
 }
+func (w *wrapInst) pin() {
+	w.pinned = true
+}
+func (w *wrapInst) isPinned() bool {
+	return w.pinned
+}
 func (w *wrapInst) initialOrdering() core.Ordering {
 	return w.before.ordering
 }
 func (w *wrapInst) wasAtomic() bool {
 	return w.before.atomic
 }
-func (w *wrapInst) Mutate() (enum.AtomicOrdering, bool) {
-	if !w.after.atomic {
+func (w *wrapInst) values(after bool) wrapValues {
+	if after {
+		return w.after
+	}
+	return w.before
+}
+
+// reset sets the state of the instruction without pending mutations.
+func (w *wrapInst) reset(v wrapValues) {
+	w.before = v
+	w.after = v
+}
+
+// commit makes the mutation of the instruction its initial state.
+func (w *wrapInst) commit() {
+	v := w.after
+	if !v.atomic {
+		v.ordering = core.Invalid
+	} else if v.ordering == core.Invalid {
+		v.ordering = core.SeqCst
+	}
+	w.reset(v)
+}
+
+// removed returns true if the instruction is not part of the module anymore,
+// ie, the instruction is a fence that has been relaxed and recorded.
+func (w *wrapInst) removed() bool {
+	_, ok := w.inst.(*ir.InstFence)
+	return ok && w.before.ordering == core.Relaxed
+}
+
+// irOrdering returns the LLVM ordering and atomic flag of the given values.
+func irOrdering(v wrapValues) (enum.AtomicOrdering, bool) {
+	if !v.atomic {
 		return toAtomicOrdering(core.Invalid), false
 	}
-	if w.after.ordering == core.Invalid {
+	if v.ordering == core.Invalid {
 		return toAtomicOrdering(core.SeqCst), true
 	}
-	return toAtomicOrdering(w.after.ordering), true
-}
-func (w *wrapInst) Unmutate() (enum.AtomicOrdering, bool) {
-	return toAtomicOrdering(w.before.ordering), w.before.atomic
+	return toAtomicOrdering(v.ordering), true
 }
 
 type wrapInstFence struct {
@@ -88,16 +121,30 @@ type wrapInstFence struct {
 	wrapInst
 }
 
+func (w *wrapInstFence) apply(v wrapValues) {
+	w.Ordering, _ = irOrdering(v)
+}
+
+func (w *wrapInstFence) render(v wrapValues) string {
+	if v.ordering == core.Relaxed {
+		return ""
+	}
+	c := *w.InstFence
+	c.Ordering, _ = irOrdering(v)
+	return c.LLString()
+}
+
 func (w *wrapInstFence) LLString() string {
-	if w.isMutation() {
-		w.Ordering, _ = w.Mutate()
-		defer func() {
-			w.Ordering, _ = w.Unmutate()
-		}()
+	if w.marker != "" {
+		return w.marker
 	}
 	if w.after.ordering == core.Relaxed {
 		return ""
 	}
+	if w.isMutation() {
+		w.apply(w.after)
+		defer w.apply(w.before)
+	}
 	return w.InstFence.LLString()
 }
 
@@ -106,12 +153,23 @@ type wrapInstLoad struct {
 	wrapInst
 }
 
+func (w *wrapInstLoad) apply(v wrapValues) {
+	w.Ordering, w.Atomic = irOrdering(v)
+}
+
+func (w *wrapInstLoad) render(v wrapValues) string {
+	c := *w.InstLoad
+	c.Ordering, c.Atomic = irOrdering(v)
+	return c.LLString()
+}
+
 func (w *wrapInstLoad) LLString() string {
+	if w.marker != "" {
+		return w.marker
+	}
 	if w.isMutation() {
-		w.Ordering, w.Atomic = w.Mutate()
-		defer func() {
-			w.Ordering, w.Atomic = w.Unmutate()
-		}()
+		w.apply(w.after)
+		defer w.apply(w.before)
 	}
 	return w.InstLoad.LLString()
 }
@@ -121,12 +179,23 @@ type wrapInstStore struct {
 	wrapInst
 }
 
+func (w *wrapInstStore) apply(v wrapValues) {
+	w.Ordering, w.Atomic = irOrdering(v)
+}
+
+func (w *wrapInstStore) render(v wrapValues) string {
+	c := *w.InstStore
+	c.Ordering, c.Atomic = irOrdering(v)
+	return c.LLString()
+}
+
 func (w *wrapInstStore) LLString() string {
+	if w.marker != "" {
+		return w.marker
+	}
 	if w.isMutation() {
-		w.Ordering, w.Atomic = w.Mutate()
-		defer func() {
-			w.Ordering, w.Atomic = w.Unmutate()
-		}()
+		w.apply(w.after)
+		defer w.apply(w.before)
 	}
 	return w.InstStore.LLString()
 }
@@ -136,12 +205,23 @@ type wrapInstAtomicRMW struct {
 	wrapInst
 }
 
+func (w *wrapInstAtomicRMW) apply(v wrapValues) {
+	w.Ordering, _ = irOrdering(v)
+}
+
+func (w *wrapInstAtomicRMW) render(v wrapValues) string {
+	c := *w.InstAtomicRMW
+	c.Ordering, _ = irOrdering(v)
+	return c.LLString()
+}
+
 func (w *wrapInstAtomicRMW) LLString() string {
+	if w.marker != "" {
+		return w.marker
+	}
 	if w.isMutation() {
-		w.Ordering, _ = w.Mutate()
-		defer func() {
-			w.Ordering, _ = w.Unmutate()
-		}()
+		w.apply(w.after)
+		defer w.apply(w.before)
 	}
 	return w.InstAtomicRMW.LLString()
 }
@@ -151,20 +231,28 @@ type wrapInstCmpXchg struct {
 	wrapInst
 }
 
+func (w *wrapInstCmpXchg) apply(v wrapValues) {
+	w.SuccessOrdering, _ = irOrdering(v)
+	w.FailureOrdering = w.SuccessOrdering
+	if w.FailureOrdering == enum.AtomicOrderingRelease {
+		w.FailureOrdering = enum.AtomicOrderingMonotonic
+	}
+}
+
+func (w *wrapInstCmpXchg) render(v wrapValues) string {
+	c := wrapInstCmpXchg{InstCmpXchg: new(ir.InstCmpXchg)}
+	*c.InstCmpXchg = *w.InstCmpXchg
+	c.apply(v)
+	return c.InstCmpXchg.LLString()
+}
+
 func (w *wrapInstCmpXchg) LLString() string {
+	if w.marker != "" {
+		return w.marker
+	}
 	if w.isMutation() {
-		w.SuccessOrdering, _ = w.Mutate()
-		w.FailureOrdering = w.SuccessOrdering
-		if w.FailureOrdering == enum.AtomicOrderingRelease {
-			w.FailureOrdering = enum.AtomicOrderingMonotonic
-		}
-		defer func() {
-			w.SuccessOrdering, _ = w.Unmutate()
-			w.FailureOrdering = w.SuccessOrdering
-			if w.FailureOrdering == enum.AtomicOrderingRelease {
-				w.FailureOrdering = enum.AtomicOrderingMonotonic
-			}
-		}()
+		w.apply(w.after)
+		defer w.apply(w.before)
 	}
 	return w.InstCmpXchg.LLString()
 }
@@ -172,8 +260,13 @@ func (w *wrapInstCmpXchg) LLString() string {
 type wrapInstruction interface {
 	ir.Instruction
 	isAtomic(after bool) bool
-	setAtomic(a bool)
 	getOrdering(after bool) core.Ordering
-	setOrdering(o core.Ordering)
+	pin()
+	isPinned() bool
+	wrap() *wrapInst
 	diff() *diffEntry
+	values(after bool) wrapValues
+	removed() bool
+	apply(v wrapValues)         // sets the LLVM instruction to the values
+	render(v wrapValues) string // prints a copy of the instruction with the values
 }
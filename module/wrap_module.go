@@ -16,7 +16,9 @@ import (
 type wrapModule struct {
 	*ir.Module
 	sync.Mutex
-	imap wrapInstSelection
+	imap    wrapInstSelection
+	tmpl    *template
+	threads []*threadEntry
 }
 
 func loadModule(fn string, cfg Config) (*wrapModule, error) {
@@ -26,12 +28,23 @@ func loadModule(fn string, cfg Config) (*wrapModule, error) {
 	if err != nil {
 		return nil, err
 	}
+	return analyzeModule(fn, mod, cfg)
+}
+
+func analyzeModule(fn string, mod *ir.Module, cfg Config) (*wrapModule, error) {
 	wmod := newWrapModule(mod)
 
 	logger.Infof("Analyze '%s'", fn)
 	if err := wmod.Visit(cfg.EntryFunc, analyze(wmod), cfg); err != nil {
 		return nil, err
 	}
+	if err := wmod.pinSelected(cfg); err != nil {
+		return nil, err
+	}
+	if cfg.InsertFences {
+		wmod.addInsertions()
+	}
+	wmod.annotateThreads(cfg)
 	return wmod, nil
 }
 
@@ -55,6 +68,9 @@ func (wm *wrapModule) addInst(id int, in wrapInstruction) {
 func (wm *wrapModule) get(sel core.Selection, after bool) wrapInstSelection {
 	insts := make(wrapInstSelection)
 	for ir, i := range wm.imap {
+		if i.removed() {
+			continue
+		}
 		if matchInstSelection(i, sel.Group(), after) {
 			insts[ir] = i
 		}
@@ -64,6 +80,8 @@ func (wm *wrapModule) get(sel core.Selection, after bool) wrapInstSelection {
 
 func getInstSelection(i wrapInstruction, after bool) core.Selection {
 	switch i := i.(type) {
+	case *wrapInstInsertion:
+		return core.SelectionInsertions
 	case *wrapInstAtomicRMW:
 		return core.SelectionRMWs
 	case *wrapInstCmpXchg:
@@ -0,0 +1,205 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package optimizer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"vsync/checker"
+	"vsync/core"
+	"vsync/logger"
+	"vsync/tools"
+)
+
+const checkpointVersion = 1
+
+// checkpoint is the state of an optimization run persisted by the driver. A
+// run is resumed from the position of the strategy in the current round with
+// the filter, tau and stats of the run.
+type checkpoint struct {
+	Version   int                  `json:"version"`
+	Key       string               `json:"key"`       // hash of the input module and the configuration
+	Initial   string               `json:"initial"`   // bitseq the current round started with
+	Bitseq    string               `json:"bitseq"`    // current bitseq of the strategy
+	Position  int                  `json:"position"`  // next bit of lr, granularity of ddmin or group of hier
+	Solutions []checkpointSolution `json:"solutions"` // accepted in the current round, oldest first
+	Filter    []string             `json:"filter"`    // failed bitseqs
+	Correct   []string             `json:"correct"`   // correct bitseqs
+	Tau       time.Duration        `json:"tau"`
+	Stats     statsState           `json:"stats"`
+}
+
+type checkpointSolution struct {
+	Bitseq string              `json:"bitseq"`
+	Status checker.CheckStatus `json:"status"`
+}
+
+// encodeBitseq returns a binary string of the bitseq, which keeps its length.
+func encodeBitseq(bs core.Bitseq) string {
+	return "0b" + bs.ToBinString()
+}
+
+func decodeBitseq(s string) (core.Bitseq, error) {
+	if s == "0b" {
+		return core.NewBitseq(0), nil
+	}
+	return core.FromString(s)
+}
+
+func encodeFilter(fs filterSet) []string {
+	r := []string{}
+	for so := range fs {
+		r = append(r, "0b"+so)
+	}
+	sort.Strings(r)
+	return r
+}
+
+func decodeFilter(bitseqs []string) (filterSet, error) {
+	fs := make(filterSet)
+	for _, s := range bitseqs {
+		bs, err := decodeBitseq(s)
+		if err != nil {
+			return nil, err
+		}
+		fs.Set(bs)
+	}
+	return fs, nil
+}
+
+// checkpointKey identifies the module and the configuration of a run. The
+// number of jobs and the checkpoint settings do not change the result and are
+// not part of the key.
+func checkpointKey(m checker.DumpableModule, at core.Selection, cfg DriverConfig) string {
+	h := sha256.New()
+	fmt.Fprintln(h, m.String())
+	fmt.Fprintln(h, at, cfg.BitsPerOp, cfg.Filter, encodeBitseq(cfg.InitialBitseq), cfg.GenmcOpts,
+		cfg.Alpha, cfg.Tau, cfg.Strategy, cfg.ErrorAsInvalid, encodeBitseq(cfg.Pinned), cfg.Objective, cfg.Costs,
+		cfg.Checker)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Resume loads the checkpoint file of the configuration. The next call to Run
+// continues the run saved in the checkpoint instead of starting a new one. It
+// fails if the checkpoint belongs to a different module or configuration.
+func (d *Driver) Resume(m MutableModule, at core.Selection) error {
+	fn := d.cfg.Checkpoint
+	data, err := os.ReadFile(tools.FromSlash(fn))
+	if err != nil {
+		return err
+	}
+	var cp checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return fmt.Errorf("%s: %v", fn, err)
+	}
+	if cp.Version != checkpointVersion {
+		return fmt.Errorf("%s: unsupported checkpoint version %d", fn, cp.Version)
+	}
+	if cp.Key != checkpointKey(m, at, d.cfg) {
+		return fmt.Errorf("%s: checkpoint of a different module or configuration", fn)
+	}
+
+	filter, err := decodeFilter(cp.Filter)
+	if err != nil {
+		return fmt.Errorf("%s: %v", fn, err)
+	}
+	correct, err := decodeFilter(cp.Correct)
+	if err != nil {
+		return fmt.Errorf("%s: %v", fn, err)
+	}
+	if err := d.stats.restore(cp.Stats); err != nil {
+		return fmt.Errorf("%s: %v", fn, err)
+	}
+	d.mu.Lock()
+	d.filter, d.correct = filter, correct
+	d.mu.Unlock()
+	d.resumed = &cp
+	return nil
+}
+
+// resumePoint returns the bitseq, the strategy position and the solutions of
+// the resumed round.
+func (cp *checkpoint) resumePoint() (core.Bitseq, core.Bitseq, int, []Solution, error) {
+	initial, err := decodeBitseq(cp.Initial)
+	if err != nil {
+		return initial, initial, 0, nil, err
+	}
+	bs, err := decodeBitseq(cp.Bitseq)
+	if err != nil {
+		return initial, bs, 0, nil, err
+	}
+	var path []Solution
+	for _, s := range cp.Solutions {
+		sbs, err := decodeBitseq(s.Bitseq)
+		if err != nil {
+			return initial, bs, 0, nil, err
+		}
+		path = append(path, Solution{bs: sbs, status: s.Status})
+	}
+	return initial, bs, cp.Position, path, nil
+}
+
+// progress is called by the strategies at the start of each step with the
+// current bitseq and the position in the strategy. The checkpoint is saved if
+// the checkpoint interval elapsed since the last save.
+func (d *Driver) progress(bs core.Bitseq, pos int) {
+	if d.cfg.Checkpoint == "" || time.Since(d.saved) < d.cfg.CheckpointInterval {
+		return
+	}
+	if err := d.saveCheckpoint(bs, pos); err != nil {
+		logger.Warnf("could not save checkpoint: %v", err)
+	}
+	d.saved = time.Now()
+}
+
+// accept records a solution found in the current round.
+func (d *Driver) accept(s Solution) {
+	d.path = append(d.path, s)
+}
+
+func (d *Driver) saveCheckpoint(bs core.Bitseq, pos int) error {
+	cp := checkpoint{
+		Version:  checkpointVersion,
+		Key:      d.key,
+		Initial:  encodeBitseq(d.round),
+		Bitseq:   encodeBitseq(bs),
+		Position: pos,
+		Tau:      d.tau,
+		Stats:    d.stats.state(),
+	}
+	for _, s := range d.path {
+		cp.Solutions = append(cp.Solutions, checkpointSolution{Bitseq: encodeBitseq(s.bs), Status: s.status})
+	}
+	d.mu.Lock()
+	cp.Filter = encodeFilter(d.filter)
+	cp.Correct = encodeFilter(d.correct)
+	d.mu.Unlock()
+
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+	// replace the checkpoint at once, so that a crash does not corrupt it
+	fn := tools.FromSlash(d.cfg.Checkpoint)
+	if err := os.WriteFile(fn+".tmp", append(data, '\n'), 0600); err != nil {
+		return err
+	}
+	return os.Rename(fn+".tmp", fn)
+}
+
+// removeCheckpoint deletes the checkpoint of a completed run.
+func (d *Driver) removeCheckpoint() {
+	if d.cfg.Checkpoint == "" {
+		return
+	}
+	if err := os.Remove(tools.FromSlash(d.cfg.Checkpoint)); err != nil && !os.IsNotExist(err) {
+		logger.Warnf("could not remove checkpoint: %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package optimizer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"vsync/checker"
+	"vsync/core"
+)
+
+func TestCheckpointResume(t *testing.T) {
+	var (
+		dir    = t.TempDir()
+		fn     = filepath.Join(dir, "run.checkpoint.json")
+		crash  = filepath.Join(dir, "crash.checkpoint.json")
+		oracle = map[string]checker.CheckStatus{
+			"111110": checker.CheckOK,
+			"111010": checker.CheckOK,
+			"101010": checker.CheckOK,
+		}
+		cfg = DriverConfig{Filter: Rlx, Strategy: LR, Checkpoint: fn}
+	)
+	newModule := func() *oracleModule {
+		return &oracleModule{initial: core.MustFromBinString("111111")}
+	}
+
+	// complete run, the state after the fifth check is kept as if the run
+	// crashed at that point
+	c := &oracleChecker{oracle: oracle}
+	c.hook = func() {
+		if len(c.checked) == 5 {
+			data, err := os.ReadFile(fn)
+			assert.Nil(t, err)
+			assert.Nil(t, os.WriteFile(crash, data, 0600))
+		}
+	}
+	want := NewDriver(cfg, c, NewStats()).Run(ctx, newModule(), core.SelectionAtomic)
+	assert.Equal(t, "101010", want.Bitseq().ToBinString())
+	total := len(c.checked)
+
+	// the checkpoint of a completed run is removed
+	_, err := os.Stat(fn)
+	assert.True(t, os.IsNotExist(err))
+
+	// the resumed run has the same result without repeating the checks
+	// done before the checkpoint
+	cfg.Checkpoint = crash
+	rc := &oracleChecker{oracle: oracle}
+	sts := NewStats()
+	d := NewDriver(cfg, rc, sts)
+	assert.Nil(t, d.Resume(newModule(), core.SelectionAtomic))
+	got := d.Run(ctx, newModule(), core.SelectionAtomic)
+	assert.Equal(t, want.Bitseq().ToBinString(), got.Bitseq().ToBinString())
+	assert.Less(t, len(rc.checked), total)
+	assert.Equal(t, total, sts.Count(Total))
+	assert.Equal(t, c.checked[total-len(rc.checked):], rc.checked)
+}
+
+func TestCheckpointMismatch(t *testing.T) {
+	var (
+		fn  = filepath.Join(t.TempDir(), "run.checkpoint.json")
+		cfg = DriverConfig{Filter: Rlx, Strategy: LR, Checkpoint: fn, Checker: "genmc tso"}
+		m   = &oracleModule{initial: core.MustFromBinString("1111")}
+	)
+	d := NewDriver(cfg, &oracleChecker{}, NewStats())
+	d.key = checkpointKey(m, core.SelectionAtomic, cfg)
+	d.round = m.initial
+	assert.Nil(t, d.saveCheckpoint(m.initial, 0))
+
+	// the same module and configuration
+	assert.Nil(t, NewDriver(cfg, &oracleChecker{}, NewStats()).Resume(m, core.SelectionAtomic))
+
+	// a different memory model
+	other := cfg
+	other.Checker = "genmc arm8"
+	assert.NotNil(t, NewDriver(other, &oracleChecker{}, NewStats()).Resume(m, core.SelectionAtomic))
+
+	// a different configuration
+	cfg.Strategy = DDmin
+	assert.NotNil(t, NewDriver(cfg, &oracleChecker{}, NewStats()).Resume(m, core.SelectionAtomic))
+}
@@ -0,0 +1,164 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package optimizer
+
+import (
+	"fmt"
+	"strings"
+
+	"vsync/checker"
+	"vsync/core"
+)
+
+// Harness is a client program of a library optimized as part of a Composite.
+type Harness interface {
+	MutableModule
+	Locations(sel core.Selection) ([]string, error)
+	Kinds(sel core.Selection) []core.AtomicOp
+}
+
+// Composite is the module of several harnesses of a library. Its operations
+// are the source locations of the operations of the harnesses with their
+// kind, so that the operations of all harnesses of the same kind at the same
+// location share their ordering. Operations of different kinds at the same
+// location, eg, the load and the store of a macro, are kept apart since the
+// same bits encode different orderings for them.
+// Checkers of the checker.Parts kind check each harness; a bitseq of the
+// composite is correct only if all harnesses are correct.
+type Composite struct {
+	names   []string
+	harness []Harness
+	sel     core.Selection
+	locs    []string        // shared locations in order of appearance
+	kinds   []core.AtomicOp // kind of the operations of each shared location
+	ops     [][]int         // ops[h][i] is the shared location of the i-th operation of harness h
+}
+
+// NewComposite returns the composite module of the harnesses with the given
+// names for the selection.
+func NewComposite(names []string, harnesses []Harness, sel core.Selection) (*Composite, error) {
+	if len(names) != len(harnesses) {
+		return nil, fmt.Errorf("%d names for %d harnesses", len(names), len(harnesses))
+	}
+	c := &Composite{names: names, harness: harnesses, sel: sel}
+
+	// the locations are numbered in the order they appear in the harnesses
+	type key struct {
+		loc  string
+		kind core.AtomicOp
+	}
+	index := make(map[key]int)
+	for h, m := range harnesses {
+		locs, err := m.Locations(sel)
+		if err != nil {
+			return nil, fmt.Errorf("harness %s: %v", names[h], err)
+		}
+		kinds := m.Kinds(sel)
+		if n := m.Assignment(sel).Bs.Length(); n != len(locs)*u2 || len(kinds) != len(locs) {
+			return nil, fmt.Errorf("harness %s: %d locations and %d kinds for %d bits",
+				names[h], len(locs), len(kinds), n)
+		}
+		ops := make([]int, len(locs))
+		for i, loc := range locs {
+			k, has := index[key{loc, kinds[i]}]
+			if !has {
+				k = len(c.locs)
+				index[key{loc, kinds[i]}] = k
+				c.locs = append(c.locs, loc)
+				c.kinds = append(c.kinds, kinds[i])
+			}
+			ops[i] = k
+		}
+		c.ops = append(c.ops, ops)
+	}
+	return c, nil
+}
+
+// Locations returns the shared locations, ie, the operations of the composite
+// in bitseq order. A location appears once per kind of its operations.
+func (c *Composite) Locations() []string {
+	return c.locs
+}
+
+// Kinds returns the kind of each operation of the composite.
+func (c *Composite) Kinds() []core.AtomicOp {
+	return c.kinds
+}
+
+// Origin returns the first harness with an operation at the k-th location and
+// the index of the operation in the harness.
+func (c *Composite) Origin(k int) (int, int) {
+	for h, ops := range c.ops {
+		for i, l := range ops {
+			if l == k {
+				return h, i
+			}
+		}
+	}
+	return -1, -1
+}
+
+// Lift returns the bitseq of the composite with the bits of the operations of
+// harness h set as in bs. If several operations have the same location, the
+// bits of all of them are set.
+func (c *Composite) Lift(h int, bs core.Bitseq) core.Bitseq {
+	r := core.NewBitseq(len(c.locs) * u2)
+	for _, b := range bs.Indices() {
+		r = r.Set(c.ops[h][b/u2]*u2 + b%u2)
+	}
+	return r
+}
+
+// Project returns the bitseq of harness h for the bitseq of the composite.
+func (c *Composite) Project(h int, bs core.Bitseq) core.Bitseq {
+	r := core.NewBitseq(len(c.ops[h]) * u2)
+	for i, l := range c.ops[h] {
+		for b := 0; b < u2; b++ {
+			if bs.Intersect(core.NewBitseq(bs.Length()).Set(l*u2 + b)) {
+				r = r.Set(i*u2 + b)
+			}
+		}
+	}
+	return r
+}
+
+// Assignment returns the assignment of the locations, where each location has
+// the strongest ordering of its operations in the harnesses.
+func (c *Composite) Assignment(sel core.Selection) core.Assignment {
+	bs := core.NewBitseq(len(c.locs) * u2)
+	for h, m := range c.harness {
+		bs = bs.Or(c.Lift(h, m.Assignment(sel).Bs))
+	}
+	return core.Assignment{Bs: bs, Sel: sel}
+}
+
+// Mutate applies the assignment of the locations to all harnesses.
+func (c *Composite) Mutate(a core.Assignment) error {
+	if a.Sel != c.sel {
+		return fmt.Errorf("composite of selection %v cannot be mutated with selection %v", c.sel, a.Sel)
+	}
+	for h, m := range c.harness {
+		if err := m.Mutate(core.Assignment{Bs: c.Project(h, a.Bs), Sel: a.Sel}); err != nil {
+			return fmt.Errorf("harness %s: %v", c.names[h], err)
+		}
+	}
+	return nil
+}
+
+// Parts returns the harnesses to check.
+func (c *Composite) Parts() []checker.Part {
+	var parts []checker.Part
+	for h, m := range c.harness {
+		parts = append(parts, checker.Part{Name: c.names[h], Module: m})
+	}
+	return parts
+}
+
+func (c *Composite) String() string {
+	var sb strings.Builder
+	for h, m := range c.harness {
+		fmt.Fprintf(&sb, "; harness %s\n%s\n", c.names[h], m.String())
+	}
+	return sb.String()
+}
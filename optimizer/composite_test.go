@@ -0,0 +1,126 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package optimizer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"vsync/checker"
+	"vsync/core"
+)
+
+// locModule is a harness with operations at the given locations. The
+// operations are RMWs unless their kinds are given.
+type locModule struct {
+	locs  []string
+	kinds []core.AtomicOp
+	bs    core.Bitseq
+}
+
+func (m *locModule) String() string                 { return "harness" }
+func (m *locModule) Mutate(a core.Assignment) error { m.bs = a.Bs; return nil }
+func (m *locModule) Assignment(_ core.Selection) core.Assignment {
+	return core.Assignment{Bs: m.bs, Sel: core.SelectionAtomic}
+}
+func (m *locModule) Locations(_ core.Selection) ([]string, error) {
+	if m.locs == nil {
+		return nil, errors.New("no locations")
+	}
+	return m.locs, nil
+}
+func (m *locModule) Kinds(_ core.Selection) []core.AtomicOp {
+	if m.kinds != nil {
+		return m.kinds
+	}
+	kinds := make([]core.AtomicOp, len(m.locs))
+	for i := range kinds {
+		kinds[i] = core.RMW
+	}
+	return kinds
+}
+
+// harnessChecker checks each harness with its oracle on the bitseq.
+type harnessChecker map[*locModule]func(core.Bitseq) bool
+
+func (c harnessChecker) Check(_ context.Context, m checker.DumpableModule) (checker.CheckResult, error) {
+	h := m.(*locModule)
+	if c[h](h.bs) {
+		return checker.CheckResult{Status: checker.CheckOK}, nil
+	}
+	return checker.CheckResult{Status: checker.CheckNotSafe}, nil
+}
+
+func (c harnessChecker) GetVersion() string { return "v0.0.0" }
+
+func TestComposite(t *testing.T) {
+	var (
+		a = &locModule{locs: []string{"lib.h:1", "lib.h:2"}, bs: core.MustFromBinString("1111")}
+		b = &locModule{locs: []string{"lib.h:3", "lib.h:2"}, bs: core.MustFromBinString("0111")}
+	)
+	c, err := NewComposite([]string{"a", "b"}, []Harness{a, b}, core.SelectionAtomic)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"lib.h:1", "lib.h:2", "lib.h:3"}, c.Locations())
+
+	// the strongest ordering of each location
+	assert.Equal(t, "111111", c.Assignment(core.SelectionAtomic).Bs.ToBinString())
+	h, op := c.Origin(2)
+	assert.Equal(t, 1, h)
+	assert.Equal(t, 0, op)
+
+	assert.Nil(t, c.Mutate(core.Assignment{Bs: core.MustFromBinString("100100"), Sel: core.SelectionAtomic}))
+	assert.Equal(t, "0100", a.bs.ToBinString())
+	assert.Equal(t, "0110", b.bs.ToBinString())
+	assert.NotNil(t, c.Mutate(core.Assignment{Bs: core.MustFromBinString("100100"), Sel: core.SelectionRMWs}))
+
+	// harnesses without locations cannot be combined
+	_, err = NewComposite([]string{"a", "c"}, []Harness{a, &locModule{bs: core.NewBitseq(0)}}, core.SelectionAtomic)
+	assert.NotNil(t, err)
+}
+
+func TestCompositeKinds(t *testing.T) {
+	var (
+		a = &locModule{
+			locs:  []string{"lib.h:1", "lib.h:1"},
+			kinds: []core.AtomicOp{core.Load, core.Store},
+			bs:    core.MustFromBinString("1111"),
+		}
+		b = &locModule{
+			locs:  []string{"lib.h:1"},
+			kinds: []core.AtomicOp{core.Store},
+			bs:    core.MustFromBinString("01"),
+		}
+	)
+	c, err := NewComposite([]string{"a", "b"}, []Harness{a, b}, core.SelectionAtomic)
+	assert.Nil(t, err)
+
+	// the load and the store at the same location do not share their bits
+	assert.Equal(t, []string{"lib.h:1", "lib.h:1"}, c.Locations())
+	assert.Equal(t, []core.AtomicOp{core.Load, core.Store}, c.Kinds())
+
+	// acquire load and release store
+	assert.Nil(t, c.Mutate(core.Assignment{Bs: core.MustFromBinString("0110"), Sel: core.SelectionAtomic}))
+	assert.Equal(t, "0110", a.bs.ToBinString())
+	assert.Equal(t, "01", b.bs.ToBinString())
+}
+
+func TestDriverComposite(t *testing.T) {
+	var (
+		a = &locModule{locs: []string{"lib.h:1", "lib.h:2"}, bs: core.MustFromBinString("1111")}
+		b = &locModule{locs: []string{"lib.h:2", "lib.h:3"}, bs: core.MustFromBinString("1111")}
+	)
+	// a needs lib.h:2 to be seq_cst and b needs some ordering at lib.h:3
+	tool := checker.NewParts(harnessChecker{
+		a: func(bs core.Bitseq) bool { return bs.ToBinString()[:2] == "11" },
+		b: func(bs core.Bitseq) bool { return bs.ToBinString()[:2] != "00" },
+	})
+	c, err := NewComposite([]string{"a", "b"}, []Harness{a, b}, core.SelectionAtomic)
+	assert.Nil(t, err)
+
+	s := NewDriver(DriverConfig{Filter: Rlx, Strategy: LR}, tool, NewStats()).Run(ctx, c, core.SelectionAtomic)
+	assert.Equal(t, "101100", s.Bitseq().ToBinString())
+}
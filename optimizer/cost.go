@@ -0,0 +1,156 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package optimizer
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"vsync/core"
+)
+
+// Objective defines what the optimization minimizes.
+type Objective int
+
+const (
+	// ObjectiveBits minimizes the number of set bits of the bitseq
+	ObjectiveBits Objective = iota
+	// ObjectiveCost minimizes the estimated cost of the orderings according
+	// to the cost model of the target architecture
+	ObjectiveCost
+)
+
+// CostModel is the estimated cost of each operation kind with each memory
+// ordering. Missing entries cost nothing.
+type CostModel map[core.AtomicOp]map[core.Ordering]int
+
+// costModels are the built-in cost models. The costs are rough estimates of
+// the instructions each ordering is compiled to, relative to a plain access;
+// a full barrier costs about 30.
+var costModels = map[string]CostModel{
+	// each bit costs 1, as when minimizing the number of set bits
+	"uniform": {
+		core.Fence:   {core.SeqCst: 2, core.Acquire: 1, core.Release: 1},
+		core.RMW:     {core.SeqCst: 2, core.Acquire: 1, core.Release: 1},
+		core.Cmpxchg: {core.SeqCst: 2, core.Acquire: 1, core.Release: 1},
+		core.Load:    {core.SeqCst: 2, core.Acquire: 1},
+		core.Store:   {core.SeqCst: 2, core.Release: 1},
+	},
+	// dmb ish, dmb ishld, ldar/ldapr, stlr and LSE atomics with acquire and
+	// release semantics
+	"arm8": {
+		core.Fence:   {core.SeqCst: 30, core.Acquire: 15, core.Release: 30},
+		core.RMW:     {core.SeqCst: 3, core.Acquire: 2, core.Release: 2},
+		core.Cmpxchg: {core.SeqCst: 3, core.Acquire: 2, core.Release: 2},
+		core.Load:    {core.SeqCst: 3, core.Acquire: 2},
+		core.Store:   {core.SeqCst: 3, core.Release: 2},
+	},
+	// only seq_cst fences and stores need an mfence or a locked instruction,
+	// atomic read-modify-writes are always locked
+	"tso": {
+		core.Fence: {core.SeqCst: 30},
+		core.Store: {core.SeqCst: 30},
+	},
+	// sync, lwsync and the ctrl-isync idiom for acquire
+	"power": {
+		core.Fence:   {core.SeqCst: 40, core.Acquire: 10, core.Release: 10},
+		core.RMW:     {core.SeqCst: 45, core.Acquire: 5, core.Release: 10},
+		core.Cmpxchg: {core.SeqCst: 45, core.Acquire: 5, core.Release: 10},
+		core.Load:    {core.SeqCst: 45, core.Acquire: 5},
+		core.Store:   {core.SeqCst: 40, core.Release: 10},
+	},
+	// fence rw,rw, the lighter fences around loads and stores and the aq and
+	// rl bits of AMOs
+	"riscv": {
+		core.Fence:   {core.SeqCst: 10, core.Acquire: 5, core.Release: 5},
+		core.RMW:     {core.SeqCst: 3, core.Acquire: 2, core.Release: 2},
+		core.Cmpxchg: {core.SeqCst: 3, core.Acquire: 2, core.Release: 2},
+		core.Load:    {core.SeqCst: 15, core.Acquire: 5},
+		core.Store:   {core.SeqCst: 10, core.Release: 5},
+	},
+}
+
+var (
+	opNames = map[string]core.AtomicOp{
+		"fence":   core.Fence,
+		"rmw":     core.RMW,
+		"cmpxchg": core.Cmpxchg,
+		"load":    core.Load,
+		"store":   core.Store,
+	}
+	orderingNames = map[string]core.Ordering{
+		"seq_cst": core.SeqCst,
+		"acquire": core.Acquire,
+		"release": core.Release,
+		"relaxed": core.Relaxed,
+	}
+)
+
+// CostModels returns the names of the built-in cost models.
+func CostModels() []string {
+	var names []string
+	for name := range costModels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewCostModel returns a copy of the built-in cost model with the given name.
+func NewCostModel(name string) (CostModel, error) {
+	cm, has := costModels[name]
+	if !has {
+		return nil, fmt.Errorf("unknown cost model %q (%s)", name, strings.Join(CostModels(), "|"))
+	}
+	c := make(CostModel)
+	for op, costs := range cm {
+		c[op] = make(map[core.Ordering]int)
+		for o, v := range costs {
+			c[op][o] = v
+		}
+	}
+	return c, nil
+}
+
+// Set overrides a cost given as kind.ordering=cost, eg, fence.seq_cst=50.
+func (c CostModel) Set(spec string) error {
+	key, val, found := strings.Cut(spec, "=")
+	if !found {
+		return fmt.Errorf("invalid cost %q, expected kind.ordering=cost", spec)
+	}
+	kind, ord, _ := strings.Cut(strings.TrimSpace(key), ".")
+	op, has := opNames[kind]
+	if !has {
+		return fmt.Errorf("invalid cost %q: unknown operation kind %q", spec, kind)
+	}
+	o, has := orderingNames[ord]
+	if !has {
+		return fmt.Errorf("invalid cost %q: unknown ordering %q", spec, ord)
+	}
+	v, err := strconv.Atoi(strings.TrimSpace(val))
+	if err != nil || v < 0 {
+		return fmt.Errorf("invalid cost %q: expected a non-negative integer", spec)
+	}
+	if c[op] == nil {
+		c[op] = make(map[core.Ordering]int)
+	}
+	c[op][o] = v
+	return nil
+}
+
+// Cost returns the estimated cost of the bitseq, where the i-th operation has
+// the i-th kind. Invalid orderings cost nothing.
+func (c CostModel) Cost(kinds []core.AtomicOp, bs core.Bitseq) int {
+	total := 0
+	if bs.Length() != len(kinds)*u2 {
+		return total
+	}
+	_ = bs.Translate(u2, func(k int, val int) error {
+		total += c[kinds[k]][kinds[k].GetOrdering(val)]
+		return nil
+	})
+	return total
+}
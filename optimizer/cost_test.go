@@ -0,0 +1,58 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package optimizer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"vsync/checker"
+	"vsync/core"
+)
+
+func TestCostModel(t *testing.T) {
+	cm, err := NewCostModel("arm8")
+	assert.Nil(t, err)
+	kinds := []core.AtomicOp{core.Load, core.Fence}
+
+	// seq_cst load and acquire fence
+	assert.Equal(t, 3+15, cm.Cost(kinds, core.MustFromBinString("1011")))
+	assert.Equal(t, 0, cm.Cost(kinds, core.MustFromBinString("0000")))
+
+	assert.Nil(t, cm.Set("fence.acquire=7"))
+	assert.Equal(t, 3+7, cm.Cost(kinds, core.MustFromBinString("1011")))
+
+	// the built-in model is not changed
+	orig, _ := NewCostModel("arm8")
+	assert.Equal(t, 15, orig[core.Fence][core.Acquire])
+
+	assert.NotNil(t, cm.Set("fence.acquire"))
+	assert.NotNil(t, cm.Set("barrier.acquire=1"))
+	assert.NotNil(t, cm.Set("fence.consume=1"))
+	assert.NotNil(t, cm.Set("fence.acquire=-1"))
+	_, err = NewCostModel("x86")
+	assert.NotNil(t, err)
+}
+
+func TestDriverObjectiveCost(t *testing.T) {
+	// the load or the fence can be relaxed, but not both
+	oracle := map[string]checker.CheckStatus{
+		"1100": checker.CheckOK,
+		"0011": checker.CheckOK,
+	}
+	cm, _ := NewCostModel("arm8")
+	cfg := DriverConfig{Filter: Rlx, Strategy: LR, Kinds: []core.AtomicOp{core.Load, core.Fence}, Costs: cm}
+
+	// fewer bits are found relaxing the load first
+	m := &oracleModule{initial: core.MustFromBinString("1111")}
+	s := NewDriver(cfg, &oracleChecker{oracle: oracle}, NewStats()).Run(ctx, m, core.SelectionAtomic)
+	assert.Equal(t, "1100", s.Bitseq().ToBinString())
+
+	// the seq_cst fence costs more than the seq_cst load and is relaxed first
+	cfg.Objective = ObjectiveCost
+	s = NewDriver(cfg, &oracleChecker{oracle: oracle}, NewStats()).Run(ctx, m, core.SelectionAtomic)
+	assert.Equal(t, "0011", s.Bitseq().ToBinString())
+	assert.Less(t, cm.Cost(cfg.Kinds, s.Bitseq()), cm.Cost(cfg.Kinds, core.MustFromBinString("1100")))
+}
@@ -7,11 +7,15 @@ package optimizer
 
 import (
 	"context"
+	"errors"
+	"sort"
+	"sync"
 	"time"
 
 	"vsync/checker"
 	"vsync/core"
 	"vsync/logger"
+	"vsync/module"
 )
 
 // Strategy defines the optimization strategy.
@@ -22,6 +26,9 @@ const (
 	DDmin Strategy = iota
 	// LR is the linear relaxation algorithm of the VSync paper
 	LR
+	// Hier relaxes groups of operations derived from the module structure,
+	// descending into the groups that cannot be relaxed
+	Hier
 )
 
 // DriverConfig represents the configuration of the driver
@@ -34,6 +41,16 @@ type DriverConfig struct {
 	Tau            time.Duration
 	Strategy       Strategy
 	ErrorAsInvalid bool
+	Pinned         core.Bitseq     // bits that must not be relaxed
+	Groups         [][]string      // groups of each operation from the outermost, for Hier
+	Kinds          []core.AtomicOp // kind of each operation, to prune invalid orderings
+	Jobs           int             // candidates checked concurrently, 0 or 1 to check one by one
+	Objective      Objective       // what the optimization minimizes
+	Costs          CostModel       // costs of the orderings, for ObjectiveCost and the summary
+	Checker        string          // checker and memory models, to match checkpoints with the run
+
+	Checkpoint         string        // file to save the state of the run to, empty to disable
+	CheckpointInterval time.Duration // minimum time between checkpoints
 }
 
 // Driver is the object that coordinates the optimization
@@ -41,8 +58,19 @@ type Driver struct {
 	cfg     DriverConfig
 	atype   core.Selection
 	checker checker.Tool
-	filter  filterSet
+	mu      sync.Mutex // protects filter and correct during concurrent checks
+	filter  filterSet  // failed bitseqs
+	correct filterSet  // correct bitseqs
 	stats   *Stats
+	views   viewModule // set if candidates are checked concurrently
+
+	// state of the run saved in checkpoints
+	key     string
+	round   core.Bitseq // bitseq the current round started with
+	tau     time.Duration
+	path    []Solution // solutions of the current round, oldest first
+	saved   time.Time
+	resumed *checkpoint
 }
 
 // NewDriver returns a new driver object
@@ -52,10 +80,13 @@ func NewDriver(cfg DriverConfig, c checker.Tool, stats *Stats) *Driver {
 		atype:   core.SelectionAtomic,
 		checker: c,
 		filter:  make(filterSet),
+		correct: make(filterSet),
 		stats:   stats,
 	}
 }
 
+var errInvalidOrdering = errors.New("invalid ordering")
+
 // Solution represents one possible correct assignment.
 type Solution struct {
 	bs      core.Bitseq
@@ -75,6 +106,12 @@ type MutableModule interface {
 	Assignment(sel core.Selection) core.Assignment
 }
 
+// viewModule is implemented by modules that can render an assignment without
+// being mutated. Concurrent checks require such a module.
+type viewModule interface {
+	View(a core.Assignment) (*module.View, error)
+}
+
 func (d *Driver) recheck(ctx context.Context, m MutableModule, a core.Assignment, sol []Solution) (int, time.Duration) {
 	// we are speculating, so double check most relaxed solution
 	var (
@@ -95,9 +132,9 @@ func (d *Driver) recheck(ctx context.Context, m MutableModule, a core.Assignment
 	}
 
 	elapsed := time.Since(ts)
+	d.stats.AddIteration(Iteration{Bitseq: s.bs, Status: status, Elapsed: elapsed, Recheck: true})
 	if status == checker.CheckOK {
 		logger.Println("OK     ", elapsed)
-		d.stats = NewStats()
 		return 0, elapsed
 	}
 
@@ -105,7 +142,7 @@ func (d *Driver) recheck(ctx context.Context, m MutableModule, a core.Assignment
 	d.stats.AddTime("failure", elapsed)
 
 	// remember the bs failed
-	d.filter.Set(s.bs)
+	d.reject(s.bs)
 
 	// select next initial bitseq: find most relaxed correct bs in
 	// solutions slice or use initial bs.
@@ -127,28 +164,75 @@ func (d *Driver) recheck(ctx context.Context, m MutableModule, a core.Assignment
 
 // Run starts the optimizer for a module with a given combination (bitsequence/selection).
 func (d *Driver) Run(ctx context.Context, m MutableModule, at core.Selection) Solution {
+	if d.cfg.Checkpoint != "" {
+		d.key = checkpointKey(m, at, d.cfg)
+	}
+	s := d.run(ctx, m, at)
+	d.removeCheckpoint()
+	return s
+}
+
+func (d *Driver) run(ctx context.Context, m MutableModule, at core.Selection) Solution {
 	// if tau == 0, there is no speculation
 	tau := d.cfg.Tau
 
 	// initial assignment
 	a := m.Assignment(at)
+	if p := d.cfg.Pinned; p.Length() != 0 && p.Length() != a.Bs.Length() {
+		logger.Fatalf("pinned bitseq has %d bits, expected %d", p.Length(), a.Bs.Length())
+	}
+
+	d.setViews(m)
+
+	resumed := d.resumed
+	d.resumed = nil
+	if resumed != nil {
+		tau = resumed.Tau
+	}
 
 	logger.Println("== OPTIMIZATION ==============================")
 	logger.Println()
 	for {
-		logger.Println("START  ", a.Bs, "#1 =", a.Bs.Ones(), time.Now().Format("15:04:05"))
+		var (
+			bs   = a.Bs
+			pos  = 0
+			path []Solution
+		)
+		if d.cfg.Strategy == DDmin {
+			pos = u2
+		}
+		if resumed != nil {
+			var err error
+			a.Bs, bs, pos, path, err = resumed.resumePoint()
+			if err != nil {
+				logger.Fatalf("invalid checkpoint: %v", err)
+			}
+			resumed = nil
+			logger.Println("RESUME ", bs, "position", pos, time.Now().Format("15:04:05"))
+		} else {
+			logger.Println("START  ", a.Bs, "#1 =", a.Bs.Ones(), time.Now().Format("15:04:05"))
+		}
+		d.round, d.tau, d.path = a.Bs, tau, path
+		d.saved = time.Time{}
 
 		check := d.getCheckClosure(m, at, tau)
 		var sol []Solution
 		switch d.cfg.Strategy {
 		case DDmin:
-			sol = d.ddmin2(ctx, a.Bs, check, u2)
+			sol = d.ddmin2(ctx, bs, check, pos)
 		case LR:
-			sol = d.lr(ctx, a.Bs, check)
+			sol = d.lrFrom(ctx, bs, check, pos)
+		case Hier:
+			sol = d.hier(ctx, bs, check, pos)
 		default:
 			logger.Fatal("unknown strategy")
 		}
 
+		// add the solutions found before resuming, the most relaxed first
+		for i := len(path) - 1; i >= 0; i-- {
+			sol = append(sol, path[i])
+		}
+
 		// assume input is a correct solution
 		sol = append(sol, Solution{bs: a.Bs, status: checker.CheckOK})
 		logCurrentSolutions(sol)
@@ -173,6 +257,142 @@ func (d *Driver) Run(ctx context.Context, m MutableModule, at core.Selection) So
 	}
 }
 
+// setViews enables concurrent checks if configured and supported by m.
+func (d *Driver) setViews(m MutableModule) {
+	d.views = nil
+	if d.cfg.Jobs > 1 {
+		if v, ok := m.(viewModule); ok {
+			d.views = v
+		} else {
+			logger.Warnf("module cannot be checked concurrently, checking candidates one by one")
+		}
+	}
+}
+
+// parallel returns whether candidates are checked concurrently.
+func (d *Driver) parallel() bool {
+	return d.views != nil
+}
+
+// filtered returns whether the bitseq is known to fail, either because it has
+// an invalid ordering or according to the filter.
+func (d *Driver) filtered(bs core.Bitseq) bool {
+	if !d.valid(bs) {
+		d.stats.Inc(PrunedInvalid)
+		return true
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.filter.Contains(bs, d.cfg.Filter) {
+		d.stats.Inc(PrunedFailed)
+		return true
+	}
+	return false
+}
+
+// implied returns whether the bitseq is known to be correct since it is at
+// least as strong as a correct bitseq.
+func (d *Driver) implied(bs core.Bitseq) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.correct.Covers(bs, d.cfg.Filter)
+}
+
+// confirm adds a correct bitseq to the set of correct bitseqs.
+func (d *Driver) confirm(bs core.Bitseq) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.correct == nil {
+		d.correct = make(filterSet)
+	}
+	d.correct.Set(bs)
+}
+
+// valid returns whether all orderings of the bitseq are valid for the kinds
+// of the operations, see core.AtomicOp.GetOrdering.
+func (d *Driver) valid(bs core.Bitseq) bool {
+	n := len(d.cfg.Kinds)
+	if n == 0 || bs.Length() != n*u2 {
+		return true
+	}
+	err := bs.Translate(u2, func(k int, val int) error {
+		if d.cfg.Kinds[k].GetOrdering(val) == core.Invalid {
+			return errInvalidOrdering
+		}
+		return nil
+	})
+	return err == nil
+}
+
+// cost returns the estimated cost of the bitseq according to the cost model.
+func (d *Driver) cost(bs core.Bitseq) int {
+	return d.cfg.Costs.Cost(d.cfg.Kinds, bs)
+}
+
+// rank sorts the candidates by estimated cost, the cheapest first, if the
+// objective is the cost. Otherwise, the order is kept.
+func (d *Driver) rank(cands []core.Bitseq) {
+	if d.cfg.Objective != ObjectiveCost {
+		return
+	}
+	sort.SliceStable(cands, func(i, j int) bool {
+		return d.cost(cands[i]) < d.cost(cands[j])
+	})
+}
+
+// opOrder returns the operations of bs in the order lr relaxes them. If the
+// objective is the cost, the operations whose relaxation saves the most are
+// relaxed first.
+func (d *Driver) opOrder(bs core.Bitseq) []int {
+	order := make([]int, bs.Length()/u2)
+	for k := range order {
+		order[k] = k
+	}
+	if d.cfg.Objective != ObjectiveCost {
+		return order
+	}
+	saving := make([]int, len(order))
+	for k := range order {
+		saving[k] = d.cost(bs) - d.cost(bs.Unset(k*u2, k*u2+1))
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return saving[order[i]] > saving[order[j]]
+	})
+	return order
+}
+
+// reject adds a failing bitseq to the filter.
+func (d *Driver) reject(bs core.Bitseq) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.filter.Set(bs)
+}
+
+// isPinned returns whether the i-th bit is excluded from the search space.
+func (d *Driver) isPinned(i int) bool {
+	p := d.cfg.Pinned
+	return i < p.Length() && p.Intersect(core.NewBitseq(p.Length()).Set(i))
+}
+
+// pinnedBits returns the pinned bits as a bitseq of the given length.
+func (d *Driver) pinnedBits(bits int) core.Bitseq {
+	if d.cfg.Pinned.Length() != bits {
+		return core.NewBitseq(bits)
+	}
+	return d.cfg.Pinned
+}
+
+// relaxable returns the indices of the 1-bits of bs that are not pinned.
+func (d *Driver) relaxable(bs core.Bitseq) []int {
+	var r []int
+	for _, i := range bs.Indices() {
+		if !d.isPinned(i) {
+			r = append(r, i)
+		}
+	}
+	return r
+}
+
 func adjustTau(tau time.Duration, elapsed time.Duration, alpha float64) time.Duration {
 	if alpha == 0 {
 		return tau + elapsed
@@ -211,38 +431,66 @@ func pickNext(sol []Solution, status checker.CheckStatus) int {
 
 type checkClosure func(ctx context.Context, bs core.Bitseq) (checker.CheckStatus, time.Duration)
 
+// logResult prints the outcome of a check. When checking concurrently, the
+// bitseq is printed on the same line since checks finish in any order.
+func (d *Driver) logResult(bs core.Bitseq, result string, elapsed time.Duration) {
+	if d.parallel() {
+		logger.Printf("CHECK   %v %s %v\n", bs, result, elapsed)
+		return
+	}
+	logger.Println(result, elapsed)
+}
+
 func (d *Driver) filterUpdate(bs core.Bitseq, status checker.CheckStatus, elapsed time.Duration) {
+	d.stats.AddIteration(Iteration{Bitseq: bs, Status: status, Elapsed: elapsed})
 	switch status {
 	case checker.CheckOK:
-		logger.Println("OK     ", elapsed)
+		d.logResult(bs, "OK     ", elapsed)
 		d.stats.Inc(Success)
 		d.stats.AddTime("success", elapsed)
+		d.confirm(bs)
 	case checker.CheckTimeout:
-		logger.Println("TIMEOUT", elapsed)
+		d.logResult(bs, "TIMEOUT", elapsed)
 		d.stats.Inc(Timeout)
 		d.stats.AddTime("timeout", elapsed)
 	case checker.CheckNotSafe:
-		logger.Println("NOTSAFE", elapsed)
+		d.logResult(bs, "NOTSAFE", elapsed)
 		d.stats.Inc(NotSafe)
 		d.stats.AddTime("failure", elapsed)
-		d.filter.Set(bs)
+		d.reject(bs)
 	case checker.CheckNotLive:
-		logger.Println("NOTLIVE", elapsed)
+		d.logResult(bs, "NOTLIVE", elapsed)
 		d.stats.Inc(NotLive)
 		d.stats.AddTime("failure", elapsed)
-		d.filter.Set(bs)
+		d.reject(bs)
 	case checker.CheckInvalid:
-		logger.Println("INVALID", elapsed)
+		d.logResult(bs, "INVALID", elapsed)
 		d.stats.Inc(Invalid)
-		d.filter.Set(bs)
+		d.reject(bs)
 	default:
 		logger.Fatal("unknown status")
 	}
 }
 
+// candidate returns the module to check for the bitseq. When checking
+// concurrently, it is a view of the module; otherwise, the module is mutated.
+func (d *Driver) candidate(m MutableModule, a core.Assignment) (checker.DumpableModule, error) {
+	if d.parallel() {
+		return d.views.View(a)
+	}
+	return m, m.Mutate(a)
+}
+
 func (d *Driver) getCheckClosure(m MutableModule, at core.Selection, tau time.Duration) checkClosure {
 	return func(ctx context.Context, bs core.Bitseq) (checker.CheckStatus, time.Duration) {
-		logger.Printf("CHECK   %v ", bs)
+		if !d.parallel() {
+			logger.Printf("CHECK   %v ", bs)
+		}
+		if d.implied(bs) {
+			d.stats.Inc(PrunedCorrect)
+			d.logResult(bs, "IMPLIED", 0)
+			return checker.CheckOK, 0
+		}
 		ts := time.Now()
 		if tau > 0 {
 			var cancel func()
@@ -250,23 +498,26 @@ func (d *Driver) getCheckClosure(m MutableModule, at core.Selection, tau time.Du
 			defer cancel()
 		}
 
-		if err := m.Mutate(core.Assignment{Bs: bs, Sel: at}); err != nil {
+		cm, err := d.candidate(m, core.Assignment{Bs: bs, Sel: at})
+		if err != nil {
 			elapsed := time.Since(ts)
 			logger.Debugf("Failed mutation: %v", err)
-			logger.Println("INVALID", elapsed)
+			d.logResult(bs, "INVALID", elapsed)
 			d.stats.Inc(Total)
 			d.stats.Inc(Invalid)
-			d.filter.Set(bs)
+			d.stats.AddIteration(Iteration{Bitseq: bs, Status: checker.CheckInvalid, Elapsed: elapsed})
+			d.reject(bs)
 			return checker.CheckInvalid, elapsed
 		}
-		var (
-			err error
-			r   checker.CheckResult
-		)
-		r, err = d.checker.Check(ctx, m)
+		r, err := d.checker.Check(ctx, cm)
 		status := r.Status
-
 		elapsed := time.Since(ts)
+
+		// a sibling candidate was accepted, the result is irrelevant
+		if ctx.Err() == context.Canceled {
+			logger.Debugf("Cancelled check of %v", bs)
+			return checker.CheckUndefined, elapsed
+		}
 		d.stats.Inc(Total)
 
 		if err != nil {
@@ -280,7 +531,7 @@ func (d *Driver) getCheckClosure(m MutableModule, at core.Selection, tau time.Du
 
 			// consider internal checker error as invalid
 			logger.Println("ERROR -> INVALID")
-			d.filter.Set(bs)
+			d.reject(bs)
 		}
 
 		d.filterUpdate(bs, status, elapsed)
@@ -6,57 +6,66 @@ package optimizer
 import (
 	"context"
 
-	"vsync/checker"
 	"vsync/core"
 )
 
 func (d *Driver) ddmin2(ctx context.Context, bs core.Bitseq, check checkClosure, n int) []Solution {
-	var bits = bs.Length()
-	if bs.Ones() < n {
+	var (
+		bits       = bs.Length()
+		sd   delta = d.relaxable(bs)
+		keep       = bs.And(d.pinnedBits(bits))
+	)
+	if len(sd) < n {
 		return nil
 	}
+	d.progress(bs, n)
 
-	var sd delta = bs.Indices()
 	idxs := sd.Subslices(n)
 	var deltas []core.Bitseq
 	var nablas []core.Bitseq
 
 	// check deltas
 	for _, i := range idxs {
-		delta := core.NewBitseq(bits).Set(i...)
-		if !d.filter.Contains(delta, d.cfg.Filter) {
+		delta := core.NewBitseq(bits).Set(i...).Or(keep)
+		if !d.filtered(delta) {
 			deltas = append(deltas, delta)
 		}
 	}
 
-	for _, sp := range deltas {
-		status, _ := check(ctx, sp)
-		if status == checker.CheckOK || status == checker.CheckTimeout {
-			sol := d.ddmin2(ctx, sp, check, u2)
-			return append(sol, Solution{bs: sp, status: status})
-		}
-		d.filter.Set(sp)
+	d.rank(deltas)
+	ev := d.firstAccepted(ctx, deltas, check)
+	for _, sp := range ev.failed {
+		d.reject(sp)
+	}
+	if ev.index != -1 {
+		sp := deltas[ev.index]
+		d.accept(Solution{bs: sp, status: ev.status})
+		sol := d.ddmin2(ctx, sp, check, u2)
+		return append(sol, Solution{bs: sp, status: ev.status})
 	}
 
 	for _, i := range idxs {
 		_ = i
 		delta := core.NewBitseq(bits).Set(i...)
 		nabla := bs.Xor(delta)
-		if !d.filter.Contains(nabla, d.cfg.Filter) {
+		if !d.filtered(nabla) {
 			nablas = append(nablas, nabla)
 		}
 	}
 
-	for _, sp := range nablas {
-		status, _ := check(ctx, sp)
-		if status == checker.CheckOK || status == checker.CheckTimeout {
-			sol := d.ddmin2(ctx, sp, check, max(n-1, u2))
-			return append(sol, Solution{bs: sp, status: status})
-		}
-		d.filter.Set(sp)
+	d.rank(nablas)
+	ev = d.firstAccepted(ctx, nablas, check)
+	for _, sp := range ev.failed {
+		d.reject(sp)
+	}
+	if ev.index != -1 {
+		sp := nablas[ev.index]
+		d.accept(Solution{bs: sp, status: ev.status})
+		sol := d.ddmin2(ctx, sp, check, max(n-1, u2))
+		return append(sol, Solution{bs: sp, status: ev.status})
 	}
-	if n < bs.Ones() {
-		return d.ddmin2(ctx, bs, check, min(bs.Ones(), u2*n))
+	if n < len(sd) {
+		return d.ddmin2(ctx, bs, check, min(len(sd), u2*n))
 	}
 	return nil
 }
@@ -0,0 +1,42 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package optimizer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"vsync/checker"
+	"vsync/core"
+)
+
+func TestDriverDDminPinned(t *testing.T) {
+	d := Driver{
+		stats:  NewStats(),
+		filter: make(filterSet),
+		cfg: DriverConfig{
+			Filter: Rlx,
+			Pinned: core.MustFromBinString("0011"),
+		},
+	}
+	m := &mockModule{
+		oracle: map[string]checker.CheckResult{
+			"0011": {Status: checker.CheckOK},
+			"0111": {Status: checker.CheckOK},
+			"1011": {Status: checker.CheckOK},
+		},
+	}
+
+	sol := d.ddmin2(ctx, core.MustFromBinString("1111"), getClosure(m, d.filter), u2)
+
+	// pinned bits are never relaxed
+	assert.True(t, len(sol) > 0)
+	for _, s := range sol {
+		assert.Equal(t, "11", s.Bitseq().ToBinString()[2:])
+	}
+	if len(sol) > 0 {
+		assert.Equal(t, "0111", sol[0].Bitseq().ToBinString())
+	}
+}
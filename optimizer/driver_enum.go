@@ -0,0 +1,134 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package optimizer
+
+import (
+	"context"
+	"sort"
+
+	"vsync/checker"
+	"vsync/core"
+	"vsync/logger"
+)
+
+// enumeration is the state of the search for all maximally relaxed solutions.
+type enumeration struct {
+	check   checkClosure
+	correct map[string]bool // checked bitseqs and whether they are correct
+	sol     []Solution
+}
+
+// Enumerate returns all maximally relaxed solutions reachable from the current
+// assignment, ie, the correct bitseqs none of whose relaxations is correct.
+// Bitseqs known to fail according to the filter are not checked. The
+// solutions are sorted by the number of one-bits and then by bitseq, or by
+// estimated cost first if the objective is the cost.
+// Enumeration does not speculate, the checks are not limited by tau.
+func (d *Driver) Enumerate(ctx context.Context, m MutableModule, at core.Selection) []Solution {
+	a := m.Assignment(at)
+	if p := d.cfg.Pinned; p.Length() != 0 && p.Length() != a.Bs.Length() {
+		logger.Fatalf("pinned bitseq has %d bits, expected %d", p.Length(), a.Bs.Length())
+	}
+	d.setViews(m)
+
+	logger.Println("== ENUMERATION ===============================")
+	logger.Println()
+	logger.Println("START  ", a.Bs, "#1 =", a.Bs.Ones())
+
+	e := &enumeration{
+		check:   d.getCheckClosure(m, at, 0),
+		correct: map[string]bool{a.Bs.ToBinString(): true},
+	}
+	d.enumerate(ctx, e, Solution{bs: a.Bs, status: checker.CheckOK})
+	sol := maximal(e.sol)
+	if d.cfg.Objective == ObjectiveCost {
+		sort.SliceStable(sol, func(i, j int) bool {
+			return d.cost(sol[i].bs) < d.cost(sol[j].bs)
+		})
+	}
+	return sol
+}
+
+// relaxations returns the bitseqs with one operation of bs relaxed by one
+// step, in the order lr checks them.
+func (d *Driver) relaxations(bs core.Bitseq) []core.Bitseq {
+	var r []core.Bitseq
+	for i := 0; i < bs.Length(); i += u2 {
+		if d.isPinned(i) || d.isPinned(i+1) {
+			continue
+		}
+		x := core.NewBitseq(bs.Length())
+		var seqs []core.Bitseq
+		if bs.Intersect(x.Set(i)) {
+			seqs = append(seqs, bs.Unset(i))
+		}
+		if bs.Intersect(x.Set(i + 1)) {
+			seqs = append(seqs, bs.Unset(i+1))
+		}
+		if len(seqs) == u2 {
+			seqs = append([]core.Bitseq{bs.Unset(i, i+1)}, seqs...)
+		}
+		r = append(r, seqs...)
+	}
+	return r
+}
+
+// enumerate explores the correct relaxations of s. If none is correct, s is
+// a solution.
+func (d *Driver) enumerate(ctx context.Context, e *enumeration, s Solution) {
+	var (
+		minimal = true
+		cands   []core.Bitseq
+	)
+	for _, r := range d.relaxations(s.bs) {
+		if ok, has := e.correct[r.ToBinString()]; has {
+			minimal = minimal && !ok
+			continue
+		}
+		if !d.filtered(r) {
+			cands = append(cands, r)
+		}
+	}
+
+	var next []Solution
+	for i, status := range d.checkEach(ctx, cands, e.check) {
+		ok := accepted(status)
+		e.correct[cands[i].ToBinString()] = ok
+		if !ok {
+			d.reject(cands[i])
+			continue
+		}
+		minimal = false
+		next = append(next, Solution{bs: cands[i], status: status})
+	}
+	for _, n := range next {
+		d.enumerate(ctx, e, n)
+	}
+	if minimal {
+		logger.Println("FOUND  ", s.bs, "#1 =", s.bs.Ones())
+		e.sol = append(e.sol, s)
+	}
+}
+
+// maximal removes the solutions that are stronger than other solutions and
+// sorts the rest.
+func maximal(sol []Solution) []Solution {
+	var r []Solution
+	for _, s := range sol {
+		weaker := false
+		for _, o := range sol {
+			weaker = weaker || o.bs.SubsetOf(s.bs)
+		}
+		if !weaker {
+			r = append(r, s)
+		}
+	}
+	sort.Slice(r, func(i, j int) bool {
+		if a, b := r[i].bs.Ones(), r[j].bs.Ones(); a != b {
+			return a < b
+		}
+		return r[i].bs.ToBinString() < r[j].bs.ToBinString()
+	})
+	return r
+}
@@ -0,0 +1,84 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package optimizer
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"vsync/checker"
+	"vsync/core"
+)
+
+// twoSolutions is correct for the supersets of 1100 and of 1011.
+func twoSolutions() map[string]checker.CheckStatus {
+	oracle := make(map[string]checker.CheckStatus)
+	for v := uint64(0); v < 16; v++ {
+		bs := core.FromUint(v).Fit(4)
+		for _, s := range []string{"1100", "1011"} {
+			so := core.MustFromBinString(s)
+			if so.SubsetOf(bs) || so.Equals(bs) {
+				oracle[bs.ToBinString()] = checker.CheckOK
+			}
+		}
+	}
+	return oracle
+}
+
+func solutionStrings(sol []Solution) []string {
+	var r []string
+	for _, s := range sol {
+		r = append(r, s.Bitseq().ToBinString())
+	}
+	return r
+}
+
+func TestEnumerate(t *testing.T) {
+	var (
+		c = &oracleChecker{oracle: twoSolutions()}
+		d = NewDriver(DriverConfig{Filter: Rlx}, c, NewStats())
+		m = &oracleModule{initial: core.MustFromBinString("1111")}
+	)
+	sol := d.Enumerate(ctx, m, core.SelectionAtomic)
+	assert.Equal(t, []string{"1100", "1011"}, solutionStrings(sol))
+
+	// no bitseq was checked twice
+	seen := make(map[string]bool)
+	for _, bs := range c.checked {
+		assert.False(t, seen[bs], bs)
+		seen[bs] = true
+	}
+}
+
+func TestEnumeratePinned(t *testing.T) {
+	var (
+		c   = &oracleChecker{oracle: twoSolutions()}
+		cfg = DriverConfig{Filter: Rlx, Pinned: core.MustFromBinString("0011")}
+		d   = NewDriver(cfg, c, NewStats())
+		m   = &oracleModule{initial: core.MustFromBinString("1111")}
+	)
+	sol := d.Enumerate(ctx, m, core.SelectionAtomic)
+	assert.Equal(t, []string{"1011"}, solutionStrings(sol))
+}
+
+func TestEnumerateParallel(t *testing.T) {
+	var (
+		d      = parallelDriver(4)
+		oracle = twoSolutions()
+		mu     sync.Mutex
+	)
+	check := func(_ context.Context, bs core.Bitseq) (checker.CheckStatus, time.Duration) {
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		return oracle[bs.ToBinString()], 0
+	}
+	e := &enumeration{check: check, correct: make(map[string]bool)}
+	d.enumerate(ctx, e, Solution{bs: core.MustFromBinString("1111"), status: checker.CheckOK})
+	assert.Equal(t, []string{"1100", "1011"}, solutionStrings(maximal(e.sol)))
+}
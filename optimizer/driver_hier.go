@@ -0,0 +1,163 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package optimizer
+
+import (
+	"context"
+	"fmt"
+
+	"vsync/core"
+	"vsync/logger"
+)
+
+// group is a set of operations relaxed at once by the hierarchical strategy.
+type group struct {
+	name     string
+	ops      []int // operation indices
+	children []*group
+	index    int // position in preorder
+	last     int // last position in the subtree
+}
+
+// groupTree returns the tree of groups of cfg.Groups. Each operation is a leaf
+// and groups with the same operations as their only child are merged.
+func groupTree(groups [][]string) *group {
+	root := &group{name: "all"}
+	for op, path := range groups {
+		g := root
+		g.ops = append(g.ops, op)
+		for _, name := range append(path, fmt.Sprintf("op %d", op)) {
+			var child *group
+			for _, c := range g.children {
+				if c.name == name {
+					child = c
+					break
+				}
+			}
+			if child == nil {
+				child = &group{name: name}
+				g.children = append(g.children, child)
+			}
+			child.ops = append(child.ops, op)
+			g = child
+		}
+	}
+	root.compress()
+	root.number(0)
+	return root
+}
+
+func (g *group) compress() {
+	for len(g.children) == 1 && len(g.children[0].ops) == len(g.ops) {
+		g.children = g.children[0].children
+	}
+	for _, c := range g.children {
+		c.compress()
+	}
+}
+
+// number assigns the preorder positions starting with i and returns the next
+// free position.
+func (g *group) number(i int) int {
+	g.index = i
+	i++
+	for _, c := range g.children {
+		i = c.number(i)
+	}
+	g.last = i - 1
+	return i
+}
+
+// opBits returns the bits of the operations of the group in a bitseq with the
+// given number of bits per operation.
+func (g *group) opBits(width int) []int {
+	var bits []int
+	for _, op := range g.ops {
+		for b := 0; b < width; b++ {
+			bits = append(bits, op*width+b)
+		}
+	}
+	return bits
+}
+
+// hier relaxes whole groups of operations at once, starting with the
+// outermost groups. If relaxing a group fails, its subgroups are tried; if an
+// operation cannot be relaxed completely, its ordering is weakened as in lr.
+// Groups with a position before start are not checked again.
+func (d *Driver) hier(ctx context.Context, bs core.Bitseq, check checkClosure, start int) []Solution {
+	n := len(d.cfg.Groups)
+	switch {
+	case n == 0 && bs.Length() == 0:
+		// no operations to relax
+		return nil
+	case n == 0 || bs.Length()%n != 0:
+		logger.Warnf("groups of %d operations do not match bitseq of %d bits", n, bs.Length())
+		return nil
+	}
+	var (
+		width = bs.Length() / n
+		sol   []Solution
+	)
+
+	var visit func(g *group)
+	visit = func(g *group) {
+		if g.last < start {
+			return
+		}
+		if g.index >= start {
+			d.progress(bs, g.index)
+			if s, ok := d.relaxGroup(ctx, bs, g, width, check); ok {
+				bs = s.bs
+				sol = append(sol, s)
+				d.accept(s)
+				return
+			}
+		}
+		for _, c := range g.children {
+			visit(c)
+		}
+	}
+	visit(groupTree(d.cfg.Groups))
+
+	reverseSolutions(sol)
+	return sol
+}
+
+// relaxGroup checks bs with the operations of the group relaxed. Single
+// operations that cannot be relaxed completely are weakened step by step.
+func (d *Driver) relaxGroup(ctx context.Context, bs core.Bitseq, g *group, width int,
+	check checkClosure) (Solution, bool) {
+	var bits []int
+	for _, b := range g.opBits(width) {
+		if bs.Intersect(core.NewBitseq(bs.Length()).Set(b)) && !d.isPinned(b) {
+			bits = append(bits, b)
+		}
+	}
+	if len(bits) == 0 {
+		return Solution{}, false
+	}
+
+	cands := []core.Bitseq{bs.Unset(bits...)}
+	if len(g.children) == 0 && len(bits) > 1 {
+		// a single operation, also try the weaker orderings
+		for _, b := range bits {
+			cands = append(cands, bs.Unset(b))
+		}
+	}
+	var unfiltered []core.Bitseq
+	for _, c := range cands {
+		if !d.filtered(c) {
+			unfiltered = append(unfiltered, c)
+		}
+	}
+	d.rank(unfiltered)
+	if len(unfiltered) > 0 && len(g.children) > 0 {
+		logger.Println("GROUP  ", g.name, "#ops =", len(g.ops))
+	}
+	ev := d.firstAccepted(ctx, unfiltered, check)
+	if ev.index == -1 {
+		return Solution{}, false
+	}
+	return Solution{bs: unfiltered[ev.index], status: ev.status, elapsed: ev.elapsed}, true
+}
@@ -0,0 +1,97 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package optimizer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"vsync/checker"
+	"vsync/core"
+)
+
+var hierGroups = [][]string{
+	{"file a.c", "func f", "clone vatomic_read_0"},
+	{"file a.c", "func f", "clone vatomic_write_0"},
+	{"file a.c", "func g", "clone vatomic_cmpxchg_0"},
+	{"file a.c", "func g", "clone vatomic_cmpxchg_0"},
+}
+
+func TestGroupTree(t *testing.T) {
+	root := groupTree(hierGroups)
+
+	// the file has the same operations as the root and is merged
+	assert.Equal(t, "all", root.name)
+	assert.Equal(t, []int{0, 1, 2, 3}, root.ops)
+	assert.Len(t, root.children, 2)
+
+	f, g := root.children[0], root.children[1]
+	assert.Equal(t, "func f", f.name)
+	assert.Len(t, f.children, 2)
+	assert.Empty(t, f.children[0].children)
+
+	// the clone is not a group of its own
+	assert.Equal(t, "func g", g.name)
+	assert.Equal(t, []int{2, 3}, g.ops)
+	assert.Len(t, g.children, 2)
+
+	// preorder positions
+	assert.Equal(t, 0, root.index)
+	assert.Equal(t, 6, root.last)
+	assert.Equal(t, 4, g.index)
+	assert.Equal(t, 6, g.last)
+}
+
+func TestDriverHier(t *testing.T) {
+	d := Driver{
+		stats:  NewStats(),
+		filter: make(filterSet),
+		cfg:    DriverConfig{Filter: Rlx, Groups: hierGroups},
+	}
+	// the operation 2 needs seq_cst, the others can be relaxed
+	var checked []string
+	need := core.NewBitseq(8).Set(4, 5)
+	check := func(_ context.Context, bs core.Bitseq) (checker.CheckStatus, time.Duration) {
+		checked = append(checked, bs.ToBinString())
+		if need.SubsetOf(bs) || need.Equals(bs) {
+			return checker.CheckOK, 0
+		}
+		d.reject(bs)
+		return checker.CheckNotSafe, 0
+	}
+
+	sol := d.hier(ctx, core.MustFromBinString("11111111"), check, 0)
+	assert.True(t, len(sol) > 0)
+	if len(sol) > 0 {
+		assert.Equal(t, "00110000", sol[0].Bitseq().ToBinString())
+	}
+	assert.Equal(t, []string{
+		"00000000", // all operations
+		"11110000", // func f, relaxed
+		// func g is filtered, its operations are tried one by one
+		"11000000", "11100000", "11010000", // operation 2
+		"00110000", // operation 3, relaxed
+	}, checked)
+}
+
+func TestDriverHierEmpty(t *testing.T) {
+	d := Driver{
+		stats:  NewStats(),
+		filter: make(filterSet),
+		cfg:    DriverConfig{Filter: Rlx},
+	}
+	check := func(_ context.Context, bs core.Bitseq) (checker.CheckStatus, time.Duration) {
+		t.Errorf("unexpected check of %s", bs.ToBinString())
+		return checker.CheckOK, 0
+	}
+
+	// a selection without operations has nothing to relax
+	assert.Empty(t, d.hier(ctx, core.NewBitseq(0), check, 0))
+
+	// groups that do not match the bitseq are an error, not a crash
+	assert.Empty(t, d.hier(ctx, core.MustFromBinString("11"), check, 0))
+}
@@ -5,17 +5,34 @@ package optimizer
 
 import (
 	"context"
-	"time"
 
-	"vsync/checker"
 	"vsync/core"
 )
 
 const u2 = 2
 
 func (d *Driver) lr(ctx context.Context, bs core.Bitseq, check checkClosure) []Solution {
-	var sol []Solution
-	for i := 0; i < bs.Length(); i += u2 {
+	return d.lrFrom(ctx, bs, check, 0)
+}
+
+// lrFrom relaxes the operations of bs starting with the bit start. The
+// operations are relaxed in the order of opOrder on the bitseq the round
+// started with, so that start refers to the same operation when resuming.
+func (d *Driver) lrFrom(ctx context.Context, bs core.Bitseq, check checkClosure, start int) []Solution {
+	round := d.round
+	if round.Length() != bs.Length() {
+		round = bs
+	}
+	var (
+		sol   []Solution
+		order = d.opOrder(round)
+	)
+	for p := start; p < bs.Length(); p += u2 {
+		d.progress(bs, p)
+		i := order[p/u2] * u2
+		if d.isPinned(i) || d.isPinned(i+1) {
+			continue
+		}
 		var seqs []core.Bitseq
 		x := core.NewBitseq(bs.Length())
 
@@ -28,18 +45,17 @@ func (d *Driver) lr(ctx context.Context, bs core.Bitseq, check checkClosure) []S
 		if len(seqs) == u2 {
 			seqs = append([]core.Bitseq{bs.Unset(i, i+1)}, seqs...)
 		}
+		var cands []core.Bitseq
 		for _, s := range seqs {
-			if d.filter.Contains(s, d.cfg.Filter) {
-				continue
+			if !d.filtered(s) {
+				cands = append(cands, s)
 			}
-			t := time.Now()
-			status, _ := check(ctx, s)
-			if status == checker.CheckOK || status == checker.CheckTimeout {
-				bs = s
-				sol = append(sol, Solution{bs: s, status: status, elapsed: time.Since(t)})
-				break
-			}
-
+		}
+		d.rank(cands)
+		if ev := d.firstAccepted(ctx, cands, check); ev.index != -1 {
+			bs = cands[ev.index]
+			sol = append(sol, Solution{bs: bs, status: ev.status, elapsed: ev.elapsed})
+			d.accept(sol[len(sol)-1])
 		}
 	}
 	reverseSolutions(sol)
@@ -147,3 +147,86 @@ func TestDriverLrnone(t *testing.T) {
 	// there were 3 checks
 	assert.Equal(t, u3, m.count)
 }
+
+func TestDriverLrPinned(t *testing.T) {
+	d := Driver{
+		stats:  NewStats(),
+		filter: make(filterSet),
+		cfg: DriverConfig{
+			Filter: Rlx,
+			Pinned: core.MustFromBinString("1100"),
+		},
+	}
+	m := &mockModule{
+		oracle: map[string]checker.CheckResult{
+			"1100": {Status: checker.CheckOK},
+			"1101": {Status: checker.CheckOK},
+			"1110": {Status: checker.CheckOK},
+		},
+	}
+
+	sol := d.lr(ctx, core.MustFromBinString("1111"), getClosure(m, d.filter))
+
+	// the pinned operation keeps its ordering
+	assert.True(t, len(sol) == 1)
+	if len(sol) >= 1 {
+		assert.Equal(t, "1100", sol[0].Bitseq().ToBinString())
+	}
+
+	// only the unpinned operation was checked
+	assert.Equal(t, 1, m.count)
+}
+
+// oracleModule is a module with the initial bitseq that records the last
+// mutation.
+type oracleModule struct {
+	initial core.Bitseq
+	bs      core.Bitseq
+}
+
+func (m *oracleModule) String() string                 { return "module" }
+func (m *oracleModule) Mutate(a core.Assignment) error { m.bs = a.Bs; return nil }
+func (m *oracleModule) Assignment(_ core.Selection) core.Assignment {
+	return core.Assignment{Bs: m.initial, Sel: core.SelectionAtomic}
+}
+
+// oracleChecker returns the status of the oracle for the bitseq of the module
+// and calls hook after each check.
+type oracleChecker struct {
+	oracle  map[string]checker.CheckStatus
+	checked []string
+	hook    func()
+}
+
+func (c *oracleChecker) Check(_ context.Context, m checker.DumpableModule) (checker.CheckResult, error) {
+	key := m.(*oracleModule).bs.ToBinString()
+	c.checked = append(c.checked, key)
+	if c.hook != nil {
+		c.hook()
+	}
+	status, has := c.oracle[key]
+	if !has {
+		status = checker.CheckNotSafe
+	}
+	return checker.CheckResult{Status: status}, nil
+}
+
+func (c *oracleChecker) GetVersion() string { return "v0.0.0" }
+
+func TestDriverRecheckStats(t *testing.T) {
+	var (
+		c   = &oracleChecker{oracle: map[string]checker.CheckStatus{"1110": checker.CheckOK}}
+		sts = NewStats()
+		d   = NewDriver(DriverConfig{Filter: Dup, Strategy: LR, Tau: time.Hour}, c, sts)
+	)
+	s := d.Run(ctx, &oracleModule{initial: core.MustFromBinString("1111")}, core.SelectionAtomic)
+	assert.Equal(t, "1110", s.Bitseq().ToBinString())
+	its := sts.Iterations()
+	assert.True(t, its[len(its)-1].Recheck)
+
+	// the stats of the caller are kept after a successful recheck
+	total := sts.Count(Total)
+	d.Run(ctx, &oracleModule{initial: core.MustFromBinString("11")}, core.SelectionAtomic)
+	assert.Equal(t, total+u3, sts.Count(Total))
+	assert.Len(t, sts.Iterations(), len(its)+u3)
+}
@@ -0,0 +1,133 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package optimizer
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"vsync/checker"
+	"vsync/core"
+)
+
+// evaluation is the outcome of checking a list of candidates.
+type evaluation struct {
+	index   int // index of the accepted candidate or -1
+	status  checker.CheckStatus
+	elapsed time.Duration
+	failed  []core.Bitseq // candidates checked and not accepted
+}
+
+// accepted returns whether a candidate with the status is a solution.
+func accepted(status checker.CheckStatus) bool {
+	return status == checker.CheckOK || status == checker.CheckTimeout
+}
+
+// firstAccepted checks the candidates in order and returns the first one that
+// is correct or timed out. With more than one job, up to cfg.Jobs candidates
+// are checked concurrently. Once a candidate is accepted, the checks of the
+// candidates after it are cancelled, but the ones before it are completed, so
+// that the result is the same as when checking one by one.
+func (d *Driver) firstAccepted(ctx context.Context, cands []core.Bitseq, check checkClosure) evaluation {
+	if !d.parallel() {
+		ev := evaluation{index: -1}
+		for i, bs := range cands {
+			status, elapsed := check(ctx, bs)
+			if accepted(status) {
+				ev.index, ev.status, ev.elapsed = i, status, elapsed
+				return ev
+			}
+			ev.failed = append(ev.failed, bs)
+		}
+		return ev
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		ev      = evaluation{index: -1}
+		cancels = make([]context.CancelFunc, len(cands))
+		failed  = make([]bool, len(cands))
+		slots   = make(chan struct{}, d.cfg.Jobs)
+	)
+	for i, bs := range cands {
+		slots <- struct{}{}
+		mu.Lock()
+		done := ev.index != -1
+		cctx, cancel := context.WithCancel(ctx)
+		cancels[i] = cancel
+		mu.Unlock()
+
+		// candidates are started in order, so the accepted one precedes i
+		if done || ctx.Err() != nil {
+			cancel()
+			<-slots
+			break
+		}
+
+		wg.Add(1)
+		go func(i int, bs core.Bitseq, cancel context.CancelFunc) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			defer cancel()
+
+			status, elapsed := check(cctx, bs)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case accepted(status):
+				if ev.index == -1 || i < ev.index {
+					ev.index, ev.status, ev.elapsed = i, status, elapsed
+					for j := i + 1; j < len(cancels); j++ {
+						if cancels[j] != nil {
+							cancels[j]()
+						}
+					}
+				}
+			case status == checker.CheckUndefined && cctx.Err() == context.Canceled:
+				// cancelled, nothing learned
+			default:
+				failed[i] = true
+			}
+		}(i, bs, cancel)
+	}
+	wg.Wait()
+
+	for i, f := range failed {
+		if f {
+			ev.failed = append(ev.failed, cands[i])
+		}
+	}
+	return ev
+}
+
+// checkEach checks all candidates, up to cfg.Jobs concurrently, and returns
+// their statuses in order.
+func (d *Driver) checkEach(ctx context.Context, cands []core.Bitseq, check checkClosure) []checker.CheckStatus {
+	statuses := make([]checker.CheckStatus, len(cands))
+	if !d.parallel() {
+		for i, bs := range cands {
+			statuses[i], _ = check(ctx, bs)
+		}
+		return statuses
+	}
+
+	var (
+		wg    sync.WaitGroup
+		slots = make(chan struct{}, d.cfg.Jobs)
+	)
+	for i, bs := range cands {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(i int, bs core.Bitseq) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			statuses[i], _ = check(ctx, bs)
+		}(i, bs)
+	}
+	wg.Wait()
+	return statuses
+}
@@ -0,0 +1,156 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package optimizer
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"vsync/checker"
+	"vsync/core"
+	"vsync/module"
+)
+
+type mockViews struct{}
+
+func (mockViews) View(_ core.Assignment) (*module.View, error) { return nil, nil }
+
+func parallelDriver(jobs int) *Driver {
+	return &Driver{
+		stats:  NewStats(),
+		filter: make(filterSet),
+		cfg:    DriverConfig{Filter: Rlx, Jobs: jobs},
+		views:  mockViews{},
+	}
+}
+
+// getSlowClosure returns a closure that takes the given time to check each
+// bitseq and reports which checks were cancelled.
+func getSlowClosure(d *Driver, oracle map[string]checker.CheckStatus, delay map[string]time.Duration,
+	cancelled map[string]bool) checkClosure {
+	var mu sync.Mutex
+	return func(ctx context.Context, bs core.Bitseq) (checker.CheckStatus, time.Duration) {
+		key := bs.ToBinString()
+		select {
+		case <-time.After(delay[key]):
+		case <-ctx.Done():
+			mu.Lock()
+			cancelled[key] = true
+			mu.Unlock()
+			return checker.CheckUndefined, 0
+		}
+		s := oracle[key]
+		if !accepted(s) {
+			d.reject(bs)
+		}
+		return s, delay[key]
+	}
+}
+
+func TestFirstAcceptedDeterministic(t *testing.T) {
+	d := parallelDriver(4)
+	cands := []core.Bitseq{
+		core.MustFromBinString("0001"),
+		core.MustFromBinString("0010"),
+		core.MustFromBinString("0100"),
+		core.MustFromBinString("1000"),
+	}
+	oracle := map[string]checker.CheckStatus{
+		"0001": checker.CheckNotSafe,
+		"0010": checker.CheckOK,
+		"0100": checker.CheckOK,
+		"1000": checker.CheckOK,
+	}
+	// the later candidates finish first
+	delay := map[string]time.Duration{
+		"0001": 30 * time.Millisecond,
+		"0010": 20 * time.Millisecond,
+		"0100": 1 * time.Millisecond,
+		"1000": time.Hour,
+	}
+	cancelled := make(map[string]bool)
+
+	ev := d.firstAccepted(ctx, cands, getSlowClosure(d, oracle, delay, cancelled))
+
+	// the first accepted candidate in order is chosen
+	assert.Equal(t, 1, ev.index)
+	assert.Equal(t, checker.CheckOK, ev.status)
+	assert.Equal(t, []core.Bitseq{cands[0]}, ev.failed)
+
+	// the check after the first accepted candidate was cancelled
+	assert.True(t, cancelled["1000"])
+	assert.False(t, cancelled["0001"])
+	assert.True(t, d.filter.Contains(cands[0], Rlx))
+}
+
+func TestFirstAcceptedLimit(t *testing.T) {
+	var (
+		d       = parallelDriver(2)
+		mu      sync.Mutex
+		running int
+		maxRun  int
+	)
+	check := func(ctx context.Context, bs core.Bitseq) (checker.CheckStatus, time.Duration) {
+		mu.Lock()
+		running++
+		if running > maxRun {
+			maxRun = running
+		}
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return checker.CheckNotSafe, 0
+	}
+	var cands []core.Bitseq
+	for i := 0; i < 8; i++ {
+		cands = append(cands, core.NewBitseq(8).Set(i))
+	}
+
+	ev := d.firstAccepted(ctx, cands, check)
+	assert.Equal(t, -1, ev.index)
+	assert.Len(t, ev.failed, len(cands))
+	assert.Equal(t, 2, maxRun)
+}
+
+func TestDriverLrParallel(t *testing.T) {
+	// in each step, the second candidate is accepted but the third one
+	// finishes first
+	oracle := map[string]checker.CheckStatus{
+		"1100": checker.CheckNotSafe,
+		"1110": checker.CheckOK,
+		"1101": checker.CheckOK,
+		"0010": checker.CheckNotSafe,
+		"1010": checker.CheckOK,
+		"0110": checker.CheckOK,
+	}
+	delay := map[string]time.Duration{
+		"1110": 10 * time.Millisecond,
+		"1010": 10 * time.Millisecond,
+	}
+
+	// the solutions are the same as when checking one by one
+	seq := parallelDriver(1)
+	seq.views = nil
+	want := seq.lr(ctx, core.MustFromBinString("1111"),
+		getSlowClosure(seq, oracle, delay, make(map[string]bool)))
+
+	d := parallelDriver(3)
+	sol := d.lr(ctx, core.MustFromBinString("1111"),
+		getSlowClosure(d, oracle, delay, make(map[string]bool)))
+
+	assert.Equal(t, len(want), len(sol))
+	for i := range want {
+		assert.Equal(t, want[i].Bitseq().ToBinString(), sol[i].Bitseq().ToBinString())
+	}
+	if len(sol) == 2 {
+		assert.Equal(t, "1010", sol[0].Bitseq().ToBinString())
+		assert.Equal(t, "1110", sol[1].Bitseq().ToBinString())
+	}
+}
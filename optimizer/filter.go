@@ -42,6 +42,29 @@ func (fs filterSet) Rlx(bs core.Bitseq) bool {
 	return false
 }
 
+// Covers checks if the bitsequence is at least as strong as an element of the
+// set using a filtering strategy. With a set of correct bitsequences, this
+// means that the bitsequence is correct assuming monotonicity.
+func (fs filterSet) Covers(bs core.Bitseq, s filterStrategy) bool {
+	switch s {
+	case None:
+		return false
+	case Dup:
+		return fs.Dup(bs)
+	case Rlx:
+		for so := range fs {
+			so := core.MustFromBinString(so)
+			if so.SubsetOf(bs) || so.Equals(bs) {
+				return true
+			}
+		}
+		return false
+	default:
+		logger.Fatalf("unknown filter strategy %v", s)
+	}
+	return false
+}
+
 // Contains checks if the set contains the bitsequence using a filtering strategy.
 func (fs filterSet) Contains(bs core.Bitseq, s filterStrategy) bool {
 	switch s {
@@ -47,3 +47,18 @@ func TestFilterRlx(t *testing.T) {
 	assert.True(t, f.Contains(also, Rlx))
 	assert.False(t, f.Contains(no, Rlx))
 }
+
+func TestFilterCovers(t *testing.T) {
+	var (
+		yes  = core.MustFromString("0x1")
+		also = core.MustFromString("0x3")
+		no   = core.MustFromString("0x2")
+		f    = make(filterSet)
+	)
+	f.Set(yes)
+	assert.True(t, f.Covers(yes, Rlx))
+	assert.True(t, f.Covers(also, Rlx))
+	assert.False(t, f.Covers(no, Rlx))
+	assert.False(t, f.Covers(also, Dup))
+	assert.False(t, f.Covers(yes, None))
+}
@@ -0,0 +1,50 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package optimizer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"vsync/checker"
+	"vsync/core"
+)
+
+func TestDriverPruneInvalid(t *testing.T) {
+	var (
+		c   = &oracleChecker{oracle: map[string]checker.CheckStatus{"1110": checker.CheckOK}}
+		cfg = DriverConfig{Filter: Rlx, Strategy: LR, Kinds: []core.AtomicOp{core.Load, core.Store}}
+		sts = NewStats()
+		m   = &oracleModule{initial: core.MustFromBinString("1111")}
+	)
+	s := NewDriver(cfg, c, sts).Run(ctx, m, core.SelectionAtomic)
+	assert.Equal(t, "1110", s.Bitseq().ToBinString())
+
+	// release loads and acquire stores are not checked
+	assert.Equal(t, []string{"1100", "1110", "0010", "0110"}, c.checked)
+	assert.Equal(t, 2, sts.Count(PrunedInvalid))
+}
+
+func TestDriverPruneCorrect(t *testing.T) {
+	d := NewDriver(DriverConfig{Filter: Rlx}, &oracleChecker{}, NewStats())
+	d.confirm(core.MustFromBinString("0101"))
+
+	m := &oracleModule{}
+	check := d.getCheckClosure(m, core.SelectionAtomic, 0)
+
+	// stronger than a correct bitseq
+	status, _ := check(ctx, core.MustFromBinString("0111"))
+	assert.Equal(t, checker.CheckOK, status)
+	assert.Equal(t, 1, d.stats.Count(PrunedCorrect))
+	assert.Equal(t, 0, d.stats.Count(Total))
+
+	// incomparable, the checker is run and the failure is remembered
+	status, _ = check(ctx, core.MustFromBinString("1010"))
+	assert.Equal(t, checker.CheckNotSafe, status)
+	assert.Equal(t, 1, d.stats.Count(Total))
+	assert.True(t, d.filtered(core.MustFromBinString("1000")))
+	assert.Equal(t, 1, d.stats.Count(PrunedFailed))
+	assert.Equal(t, 2, d.stats.Saved())
+}
@@ -7,7 +7,12 @@ import (
 	"fmt"
 	"log"
 	"math"
+	"sort"
+	"sync"
 	"time"
+
+	"vsync/checker"
+	"vsync/core"
 )
 
 //go:generate go run golang.org/x/tools/cmd/stringer -type=Type
@@ -34,6 +39,12 @@ const (
 	Total
 	// Timeout considered to be OK
 	Timeout
+	// PrunedFailed count: not checked since a weaker bitseq failed
+	PrunedFailed
+	// PrunedCorrect count: not checked since a stronger bitseq is correct
+	PrunedCorrect
+	// PrunedInvalid count: not checked since an ordering is invalid for the operation
+	PrunedInvalid
 )
 
 type timeStats struct {
@@ -42,13 +53,32 @@ type timeStats struct {
 	cnt  int
 }
 
-// Stats keeps tracks of count stats and timing measurements
+// Iteration is a check of a bitseq done by the driver.
+type Iteration struct {
+	Bitseq  core.Bitseq
+	Status  checker.CheckStatus
+	Elapsed time.Duration
+	Recheck bool // whether the bitseq was checked again after speculating
+}
+
+// TimeStat is the timing measurement of a tag.
+type TimeStat struct {
+	Tag   string
+	Mean  time.Duration
+	SD    time.Duration
+	Count int
+}
+
+// Stats keeps tracks of count stats and timing measurements. Stats can be
+// updated concurrently.
 type Stats struct {
+	mu     sync.Mutex
 	counts map[Type]int
 	start  time.Time
 	first  time.Time
 	last   time.Time
 	time   map[string]timeStats
+	iters  []Iteration
 }
 
 // NewStats returns a new Stats object
@@ -62,6 +92,8 @@ func NewStats() *Stats {
 
 // Inc increments the stats count of type t
 func (s *Stats) Inc(t Type) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	if t == Success {
 		s.last = time.Now()
 		if s.counts[Success] == 0 {
@@ -73,6 +105,8 @@ func (s *Stats) Inc(t Type) {
 
 // AddTime adds a time durations to a tag
 func (s *Stats) AddTime(tag string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	t := s.time[tag]
 	t.sum += float64(d)
 	t.sum2 += float64(d) * float64(d)
@@ -80,6 +114,51 @@ func (s *Stats) AddTime(tag string, d time.Duration) {
 	s.time[tag] = t
 }
 
+// AddIteration appends a check to the iteration log.
+func (s *Stats) AddIteration(it Iteration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.iters = append(s.iters, it)
+}
+
+// Iterations returns the iteration log.
+func (s *Stats) Iterations() []Iteration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Iteration{}, s.iters...)
+}
+
+// Count returns the stats count of type t.
+func (s *Stats) Count(t Type) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.counts[t]
+}
+
+// Saved returns the number of checks avoided by pruning.
+func (s *Stats) Saved() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.counts[PrunedFailed] + s.counts[PrunedCorrect] + s.counts[PrunedInvalid]
+}
+
+// Elapsed returns the time since the stats were created.
+func (s *Stats) Elapsed() time.Duration {
+	return time.Since(s.start)
+}
+
+// Times returns the timing measurements sorted by tag.
+func (s *Stats) Times() []TimeStat {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ts []TimeStat
+	for tag, t := range s.time {
+		ts = append(ts, TimeStat{Tag: tag, Mean: t.mean(), SD: t.sd(), Count: t.cnt})
+	}
+	sort.Slice(ts, func(i, j int) bool { return ts[i].Tag < ts[j].Tag })
+	return ts
+}
+
 func (ts timeStats) mean() time.Duration {
 	sum := float64(ts.sum)
 	cnt := float64(ts.cnt)
@@ -96,13 +175,89 @@ func (ts timeStats) sd() time.Duration {
 	return sd
 }
 
+// statsState is the serialized form of Stats in checkpoints.
+type statsState struct {
+	Counts     map[Type]int         `json:"counts"`
+	Times      map[string]timeState `json:"times"`
+	Iterations []iterationState     `json:"iterations"`
+	Elapsed    time.Duration        `json:"elapsed"`
+}
+
+type timeState struct {
+	Sum  float64 `json:"sum"`
+	Sum2 float64 `json:"sum2"`
+	Cnt  int     `json:"cnt"`
+}
+
+type iterationState struct {
+	Bitseq  string              `json:"bitseq"`
+	Status  checker.CheckStatus `json:"status"`
+	Elapsed time.Duration       `json:"elapsed"`
+	Recheck bool                `json:"recheck,omitempty"`
+}
+
+func (s *Stats) state() statsState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := statsState{
+		Counts:  make(map[Type]int),
+		Times:   make(map[string]timeState),
+		Elapsed: time.Since(s.start),
+	}
+	for t, c := range s.counts {
+		st.Counts[t] = c
+	}
+	for tag, t := range s.time {
+		st.Times[tag] = timeState{Sum: t.sum, Sum2: t.sum2, Cnt: t.cnt}
+	}
+	for _, it := range s.iters {
+		st.Iterations = append(st.Iterations, iterationState{
+			Bitseq:  encodeBitseq(it.Bitseq),
+			Status:  it.Status,
+			Elapsed: it.Elapsed,
+			Recheck: it.Recheck,
+		})
+	}
+	return st
+}
+
+// restore replaces the stats with the saved ones. The elapsed time continues
+// from the saved one.
+func (s *Stats) restore(st statsState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counts = make(map[Type]int)
+	for t, c := range st.Counts {
+		s.counts[t] = c
+	}
+	s.time = make(map[string]timeStats)
+	for tag, t := range st.Times {
+		s.time[tag] = timeStats{sum: t.Sum, sum2: t.Sum2, cnt: t.Cnt}
+	}
+	s.iters = nil
+	for _, it := range st.Iterations {
+		bs, err := decodeBitseq(it.Bitseq)
+		if err != nil {
+			return err
+		}
+		s.iters = append(s.iters, Iteration{Bitseq: bs, Status: it.Status, Elapsed: it.Elapsed, Recheck: it.Recheck})
+	}
+	s.start = time.Now().Add(-st.Elapsed)
+	return nil
+}
+
 // String is the string representation of the stats object.
 func (s *Stats) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	var str string
 	for k, v := range s.counts {
 		str += fmt.Sprintf("%8v: %d\n", k, v)
 	}
 
+	saved := s.counts[PrunedFailed] + s.counts[PrunedCorrect] + s.counts[PrunedInvalid]
+	str += fmt.Sprintf("\nChecks saved by pruning: %d\n", saved)
+
 	elapsed := time.Since(s.start)
 	str += fmt.Sprintf("\nTotal time: %v (%v)\n", elapsed.Seconds(), elapsed)
 
@@ -114,6 +269,8 @@ func (s *Stats) String() string {
 
 // GetTime returns the time duration spent with a specific tag.
 func (s *Stats) GetTime(tag string) (time.Duration, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	log.Println(tag, "------")
 
 	if tstats, has := s.time[tag]; has {
@@ -6,6 +6,7 @@
 package tools
 
 import (
+	"os"
 	"strings"
 
 	"vsync/logger"
@@ -16,7 +17,12 @@ func init() {
 		"Path to clang or space-separated command to run clang")
 	RegEnv("CFLAGS", "",
 		"Flags passed to clang when compiling the target file")
-
+	RegEnv("LLVM_LINK_CMD", "llvm-link",
+		"Path to llvm-link or space-separated command to run llvm-link")
+	RegEnv("LLVM_AS_CMD", "llvm-as",
+		"Path to llvm-as or space-separated command to run llvm-as")
+	RegEnv("LLVM_DIS_CMD", "llvm-dis",
+		"Path to llvm-dis or space-separated command to run llvm-dis")
 }
 
 // Compile calls clang compiler and creates an LLVM IR module using the required compiler options.
@@ -53,6 +59,123 @@ func Compile(args []string, ofile string, compileOptions []string) error {
 	return err
 }
 
+// Dependencies calls clang to list the source files and headers included by
+// the compilation of args, eg, to watch them for changes.
+func Dependencies(args []string, compileOptions []string) ([]string, error) {
+	clang, err := FindCmd("CLANG_CMD")
+	if err != nil {
+		return nil, err
+	}
+	dfile, err := Touch("deps-*.d")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = Remove(dfile)
+	}()
+
+	var opts []string
+	if cflags := GetEnv("CFLAGS"); cflags != "" {
+		opts = append(opts, strings.Split(cflags, " ")...)
+	}
+	opts = append(opts, compileOptions...)
+	opts = append(opts, "-DVSYNC_VERIFICATION", "-M", "-MF", dfile)
+	opts = append(opts, args...)
+
+	var (
+		cmd     = clang[0]
+		cmdArgs = append(clang[1:], opts...)
+	)
+	logger.Infof("%v %v", cmd, strings.Join(cmdArgs, " "))
+	out, err := RunCmd(cmd, cmdArgs, nil)
+	logger.Debugf("%v", out)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(FromSlash(dfile))
+	if err != nil {
+		return nil, err
+	}
+	return parseDependencies(string(data)), nil
+}
+
+// parseDependencies returns the prerequisites of the make rules written by
+// clang -M, without duplicates.
+func parseDependencies(rules string) []string {
+	const space = "\x00"
+	rules = strings.ReplaceAll(rules, "\\\r\n", " ")
+	rules = strings.ReplaceAll(rules, "\\\n", " ")
+	rules = strings.ReplaceAll(rules, "\\ ", space)
+
+	var (
+		deps []string
+		seen = make(map[string]bool)
+	)
+	for _, line := range strings.Split(rules, "\n") {
+		// the target ends with ": ", which also skips drive letters
+		idx := strings.Index(line, ": ")
+		if idx < 0 {
+			continue
+		}
+		for _, f := range strings.Fields(line[idx+2:]) {
+			f = strings.ReplaceAll(f, space, " ")
+			if !seen[f] {
+				seen[f] = true
+				deps = append(deps, f)
+			}
+		}
+	}
+	return deps
+}
+
+// Link calls llvm-link to merge several LLVM IR modules into a single textual LLVM IR module.
+func Link(inputs []string, ofile string) error {
+	link, err := FindCmd("LLVM_LINK_CMD")
+	if err != nil {
+		return err
+	}
+
+	var (
+		cmd     = link[0]
+		cmdArgs = append(link[1:], "-S", "-o", ofile)
+	)
+	cmdArgs = append(cmdArgs, inputs...)
+
+	logger.Info("Linking")
+	logger.Infof("%v %v", cmd, strings.Join(cmdArgs, " "))
+	out, err := RunCmd(cmd, cmdArgs, nil)
+	logger.Debugf("%v", out)
+	return err
+}
+
+// Assemble calls llvm-as to convert a textual LLVM IR module into LLVM bitcode.
+func Assemble(input, ofile string) error {
+	return runLLVMTool("LLVM_AS_CMD", "Assembling", input, ofile)
+}
+
+// Disassemble calls llvm-dis to convert an LLVM bitcode module into textual LLVM IR.
+func Disassemble(input, ofile string) error {
+	return runLLVMTool("LLVM_DIS_CMD", "Disassembling", input, ofile)
+}
+
+func runLLVMTool(key, msg, input, ofile string) error {
+	tool, err := FindCmd(key)
+	if err != nil {
+		return err
+	}
+
+	var (
+		cmd     = tool[0]
+		cmdArgs = append(tool[1:], "-o", ofile, input)
+	)
+
+	logger.Info(msg)
+	logger.Infof("%v %v", cmd, strings.Join(cmdArgs, " "))
+	out, err := RunCmd(cmd, cmdArgs, nil)
+	logger.Debugf("%v", out)
+	return err
+}
+
 type boilerplateMap map[string]string
 
 func (bp boilerplateMap) names() []string {
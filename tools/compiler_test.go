@@ -0,0 +1,22 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDependencies(t *testing.T) {
+	rules := "lock.o: test/lock.c include/vsync/atomic.h \\\n" +
+		"  include/my\\ lock.h include/vsync/atomic.h\n" +
+		"queue.o: lib/queue.c include/vsync/atomic.h\n"
+	assert.Equal(t, []string{
+		"test/lock.c",
+		"include/vsync/atomic.h",
+		"include/my lock.h",
+		"lib/queue.c",
+	}, parseDependencies(rules))
+}
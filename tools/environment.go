@@ -34,7 +34,12 @@ func FindCmd(key string) ([]string, error) {
 
 	cmds := strings.Split(val, " ")
 	if GetEnv("VSYNCER_DOCKER") == "true" {
-		return append([]string{vsyncerCmd, "docker", "--"}, cmds...), nil
+		// temporary files are in the work directory, which has to be mounted
+		dir, err := WorkDir()
+		if err != nil {
+			return nil, err
+		}
+		return append([]string{vsyncerCmd, "docker", "-v", dir, "--"}, cmds...), nil
 	}
 	return cmds, err
 }
@@ -51,6 +56,10 @@ func (e Envvar) String() string {
 
 var envVars = map[string]Envvar{}
 
+// fileEnv holds the values of environment variables set by a configuration
+// file. They are used if the variable is not set in the environment.
+var fileEnv = map[string]string{}
+
 // RegEnv registers an environment variable with a default value and a
 // description.
 func RegEnv(key, defv, desc string) {
@@ -84,6 +93,8 @@ func LookupEnv(key string) (string, error) {
 		return "", fmt.Errorf("Envvar '%s' not registered", key)
 	} else if vv, has := os.LookupEnv(key); has { //permit:os.LookupEnv
 		return vv, nil
+	} else if vv, has := fileEnv[key]; has {
+		return vv, nil
 	} else {
 		return v.Defv, nil
 	}
@@ -97,7 +108,30 @@ func IsDefaultEnv(key string) bool {
 	}
 
 	_, has := os.LookupEnv(key) //permit:os.LookupEnv
-	return !has
+	_, hasFile := fileEnv[key]
+	return !has && !hasFile
+}
+
+// IsEnvironEnv returns true if the variable is set in the environment.
+func IsEnvironEnv(key string) bool {
+	_, has := os.LookupEnv(key) //permit:os.LookupEnv
+	return has
+}
+
+// SetFileEnv sets the value of a registered environment variable as read
+// from a configuration file. The value of the environment, if set, takes
+// precedence.
+func SetFileEnv(key, val string) error {
+	if _, has := envVars[key]; !has {
+		return fmt.Errorf("Envvar '%s' not registered", key)
+	}
+	fileEnv[key] = val
+	return nil
+}
+
+// ResetFileEnv removes the values set with SetFileEnv.
+func ResetFileEnv() {
+	fileEnv = map[string]string{}
 }
 
 // GetEnvvars returns list of all registered environment variables.
@@ -9,15 +9,22 @@ import (
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 
 	"vsync/logger"
 )
 
 const fileMode = 0600
 
-// Touch creates a new file temporary file with the given file pattern
+// Touch creates a new temporary file with the given file pattern in the work
+// directory, see WorkDir.
 func Touch(pattern string) (string, error) {
-	tmp, err := ioutil.TempFile(".", pattern)
+	dir, err := WorkDir()
+	if err != nil {
+		return "", err
+	}
+	tmp, err := ioutil.TempFile(FromSlash(dir), pattern)
 	if err != nil {
 		return "", err
 	}
@@ -28,6 +35,33 @@ func Touch(pattern string) (string, error) {
 	return ToSlash(tmp.Name()), nil
 }
 
+// RelPath returns the path of fn relative to the directory dir. Relative
+// paths are taken relative to the working directory.
+func RelPath(dir, fn string) (string, error) {
+	adir, err := filepath.Abs(FromSlash(dir))
+	if err != nil {
+		return "", err
+	}
+	afn, err := filepath.Abs(FromSlash(fn))
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(adir, afn)
+	if err != nil {
+		return "", err
+	}
+	return ToSlash(filepath.ToSlash(rel)), nil
+}
+
+// ResolvePath returns the path of fn taken relative to the directory dir,
+// unless fn is absolute. It is the inverse of RelPath.
+func ResolvePath(dir, fn string) string {
+	if filepath.IsAbs(FromSlash(fn)) {
+		return fn
+	}
+	return ToSlash(filepath.Join(FromSlash(dir), FromSlash(fn)))
+}
+
 // RunCmd runs a command line with arguments and environment variable assignments
 func RunCmd(cmdl string, args, env []string) (string, error) {
 	return RunCmdContext(context.Background(), cmdl, args, env)
@@ -81,7 +115,8 @@ func FileExists(fn string) error {
 	return nil
 }
 
-func filesExist(fns []string) error {
+// FilesExist returns nil if all files exist otherwise an error
+func FilesExist(fns []string) error {
 	for _, fn := range fns {
 		if err := FileExists(fn); err != nil {
 			return err
@@ -107,8 +142,13 @@ func CopyFile(src, dst string) error {
 
 const enableRemove = true
 
-// Remove deletes as file. It can be disabled with the enableRemove flag in the source.
+// Remove deletes as file. It can be disabled with the enableRemove flag in the
+// source. Files in the work directory are kept if artifacts should be kept.
 func Remove(fn string) error {
+	if isArtifact(fn) {
+		logger.Debugf("Keep file '%s'", fn)
+		return nil
+	}
 	logger.Debugf("Remove file '%s'", fn)
 	if enableRemove {
 		return os.Remove(fn)
@@ -133,3 +173,25 @@ func Dump(m fmt.Stringer, fn string) error {
 	_, err = fmt.Fprint(out, m)
 	return err
 }
+
+// Save writes the current state of the module to a file. If the file has the
+// extension .bc, the module is saved as LLVM bitcode, otherwise as textual
+// LLVM IR.
+func Save(m fmt.Stringer, fn string) error {
+	if !strings.HasSuffix(fn, ".bc") {
+		return Dump(m, fn)
+	}
+	tmp, err := Touch("vsyncer-save-*.ll")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := Remove(tmp); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	if err := Dump(m, tmp); err != nil {
+		return err
+	}
+	return Assemble(tmp, fn)
+}
@@ -0,0 +1,123 @@
+// Copyright (C) 2024 Huawei Technologies Co., Ltd. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"vsync/logger"
+)
+
+func init() {
+	RegEnv("VSYNCER_WORK_DIR", "",
+		"Directory where the work directory of each run is created (default: system temp dir)")
+}
+
+// work is the directory holding the temporary files of the current run.
+var work struct {
+	sync.Mutex
+	base string
+	dir  string
+	keep bool
+}
+
+// SetWorkDir sets the directory in which the work directory is created and
+// whether the temporary files are kept after the run. It has to be called
+// before the work directory is used.
+func SetWorkDir(base string, keep bool) {
+	work.Lock()
+	defer work.Unlock()
+	if work.dir != "" {
+		logger.Warnf("work directory already created: %s", work.dir)
+	}
+	work.base = base
+	work.keep = keep
+}
+
+// WorkDir returns the work directory of the current run. The directory is
+// created on the first call.
+func WorkDir() (string, error) {
+	work.Lock()
+	defer work.Unlock()
+	if work.dir != "" {
+		return work.dir, nil
+	}
+	base := work.base
+	if base == "" {
+		base = GetEnv("VSYNCER_WORK_DIR")
+	}
+	if base != "" {
+		if err := os.MkdirAll(base, 0700); err != nil {
+			return "", fmt.Errorf("could not create work directory: %v", err)
+		}
+	}
+	dir, err := ioutil.TempDir(base, "vsyncer-")
+	if err != nil {
+		return "", fmt.Errorf("could not create work directory: %v", err)
+	}
+	if dir, err = filepath.Abs(dir); err != nil {
+		return "", err
+	}
+	work.dir = ToSlash(dir)
+	logger.Debugf("Work directory '%s'", work.dir)
+	return work.dir, nil
+}
+
+// WorkPath returns the path of a file with the given name in the work directory.
+func WorkPath(name string) (string, error) {
+	dir, err := WorkDir()
+	if err != nil {
+		return "", err
+	}
+	return ToSlash(filepath.Join(dir, name)), nil
+}
+
+// TempName returns a unique file name in the work directory without creating
+// the file. The last "*" in the pattern is replaced by a random string.
+func TempName(pattern string) (string, error) {
+	fn, err := Touch(pattern)
+	if err != nil {
+		return "", err
+	}
+	return fn, os.Remove(FromSlash(fn))
+}
+
+// CleanupWorkDir removes the work directory and its files unless the
+// artifacts should be kept.
+func CleanupWorkDir() {
+	work.Lock()
+	defer work.Unlock()
+	if work.dir == "" {
+		return
+	}
+	if work.keep {
+		logger.Printf("Artifacts kept in %s\n", work.dir)
+		return
+	}
+	logger.Debugf("Remove work directory '%s'", work.dir)
+	if err := os.RemoveAll(FromSlash(work.dir)); err != nil {
+		logger.Warnf("could not remove work directory: %v", err)
+	}
+	work.dir = ""
+}
+
+// isArtifact returns true if the file is in the work directory and should
+// be kept.
+func isArtifact(fn string) bool {
+	work.Lock()
+	defer work.Unlock()
+	if !work.keep || work.dir == "" {
+		return false
+	}
+	abs, err := filepath.Abs(FromSlash(fn))
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(ToSlash(abs), work.dir+"/")
+}